@@ -0,0 +1,502 @@
+// Package asm assembles the nasm syntax that the decoder prints into a flat
+// 16-bit binary, using the same encoding table as the decoder.
+//
+// It understands labels, including local labels starting with a dot,
+// expressions with $ and $$, and the directives bits 16, org, db, dw, times
+// and equ.
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"8086/decoder"
+)
+
+// Error is an error assembling a line of the source.
+type Error struct {
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type statementKind int
+
+const (
+	statementInstruction statementKind = iota
+	statementData
+	statementOrg
+	statementEqu
+)
+
+type statement struct {
+	line  int
+	label string
+	kind  statementKind
+
+	// times is the repeat count, or nil
+	times expr
+
+	// Instruction
+	name     string
+	flags    decoder.InstructionFlags
+	operands []operandExpr
+
+	// Data, with width 1 for db and 2 for dw
+	width int
+	data  []dataItem
+
+	// The value for org and equ
+	value expr
+
+	// size is the number of bytes the statement took in the last pass
+	size int
+	// minLen is the longest encoding chosen for the instruction so far.
+	// Encodings never get shorter in later passes, so that jumps between
+	// labels can't keep growing and shrinking each other.
+	minLen int
+}
+
+type dataItem struct {
+	str   string
+	isStr bool
+	x     expr
+}
+
+// maxPasses limits how many times the label addresses can change before
+// giving up.
+const maxPasses = 100
+
+// maxOutput is the most a times statement may take the output to: one
+// 64 KiB segment.
+const maxOutput = 0x10000
+
+// Assemble assembles src, returning the binary. Errors are an *Error for the
+// line they happened on.
+func Assemble(src string) ([]byte, error) {
+	stmts, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &assembler{stmts: stmts, symbols: map[string]int{}}
+	for pass := 0; pass < maxPasses; pass++ {
+		changed, err := a.pass(false)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			if _, err := a.pass(true); err != nil {
+				return nil, err
+			}
+			return a.out, nil
+		}
+	}
+	return nil, errors.New("label addresses didn't settle")
+}
+
+type assembler struct {
+	stmts   []*statement
+	symbols map[string]int
+	out     []byte
+}
+
+// pass assembles every statement, updating the symbols. Until the final pass
+// symbols that aren't defined yet are 0 and values are allowed to be out of
+// range. It returns whether any symbol or statement size changed.
+func (a *assembler) pass(final bool) (bool, error) {
+	e := &env{symbols: a.symbols, final: final}
+	a.out = a.out[:0]
+	origin, addr := 0, 0
+	changed := false
+	define := func(name string, v int) {
+		if old, ok := a.symbols[name]; !ok || old != v {
+			a.symbols[name] = v
+			changed = true
+		}
+	}
+
+	for _, s := range a.stmts {
+		e.here, e.start = addr, origin
+		e.unresolved = false
+		fail := func(err error) (bool, error) {
+			return false, &Error{Line: s.line, Err: err}
+		}
+
+		switch s.kind {
+		case statementEqu:
+			v, err := s.value.eval(e)
+			if err != nil {
+				if final {
+					err = fmt.Errorf("equ %s is unresolved or circular: %w", s.label, err)
+				}
+				return fail(err)
+			}
+			// Leave the symbol undefined until its value is known, so that
+			// an equ that refers back to itself stays undefined and is
+			// caught by the final pass instead of becoming 0.
+			if !e.unresolved {
+				define(s.label, v)
+			}
+			continue
+		case statementOrg:
+			if addr != origin {
+				return fail(errors.New("org after code"))
+			}
+			v, err := s.value.eval(e)
+			if err != nil {
+				return fail(err)
+			}
+			if e.unresolved {
+				return fail(errors.New("org must be a constant"))
+			}
+			origin, addr = v, v
+			continue
+		}
+
+		if s.label != "" {
+			define(s.label, addr)
+		}
+
+		count := 1
+		if s.times != nil {
+			n, err := s.times.eval(e)
+			if err != nil {
+				return fail(err)
+			}
+			switch {
+			case e.unresolved:
+				n = 0
+			case n < 0 && final:
+				return fail(fmt.Errorf("times count %d is negative", n))
+			case n < 0:
+				n = 0
+			case n > maxOutput:
+				return fail(fmt.Errorf("times count %d is past 64 KiB of output", n))
+			}
+			count = n
+		}
+
+		start := len(a.out)
+		for i := 0; i < count; i++ {
+			e.here = origin + len(a.out)
+			var b []byte
+			var err error
+			if s.kind == statementData {
+				b, err = e.data(s)
+			} else {
+				b, err = e.instruction(s)
+			}
+			if err != nil {
+				return fail(err)
+			}
+			a.out = append(a.out, b...)
+			if s.times != nil && len(a.out) > maxOutput {
+				return fail(fmt.Errorf("times count %d is past 64 KiB of output", count))
+			}
+		}
+
+		if size := len(a.out) - start; size != s.size {
+			s.size = size
+			changed = true
+		}
+		addr = origin + len(a.out)
+	}
+	return changed, nil
+}
+
+// instruction encodes the instruction s at e.here.
+func (e *env) instruction(s *statement) ([]byte, error) {
+	in := instruction{name: s.name, flags: s.flags}
+	for _, ox := range s.operands {
+		o, err := e.operand(ox)
+		if err != nil {
+			return nil, err
+		}
+		in.operands = append(in.operands, o)
+	}
+	// Values using symbols that aren't defined yet are meaningless, so
+	// shouldn't rule out the short encodings
+	b, err := encode(in, e.here, s.minLen, !e.final && e.unresolved)
+	var re *rangeError
+	if errors.As(err, &re) && !e.final {
+		// It might fit once the addresses have settled
+		b, err = encode(in, e.here, s.minLen, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > s.minLen {
+		s.minLen = len(b)
+	}
+	return b, nil
+}
+
+// operand evaluates the expressions in o.
+func (e *env) operand(o operandExpr) (operand, error) {
+	op := operand{
+		kind:  o.kind,
+		reg:   o.reg,
+		size:  o.size,
+		short: o.short,
+		near:  o.near,
+		far:   o.far,
+	}
+	switch o.kind {
+	case decoder.OperandMemory:
+		disp := 0
+		if o.disp != nil {
+			var err error
+			if disp, err = o.disp.eval(e); err != nil {
+				return op, err
+			}
+		}
+		if e.final && (disp < -0x8000 || disp > 0xFFFF) {
+			return op, &rangeError{"displacement", disp}
+		}
+		op.ea = decoder.EffectiveAddress{
+			Base:         o.base,
+			Index:        o.index,
+			Displacement: int16(disp),
+			Segment:      o.seg,
+		}
+	case decoder.OperandImmediate, decoder.OperandFarPointer:
+		v, err := o.imm.eval(e)
+		if err != nil {
+			return op, err
+		}
+		op.imm = v
+		if o.farSeg != nil {
+			if op.seg, err = o.farSeg.eval(e); err != nil {
+				return op, err
+			}
+		}
+	}
+	return op, nil
+}
+
+// data returns the bytes of the db or dw s.
+func (e *env) data(s *statement) ([]byte, error) {
+	var b []byte
+	for _, d := range s.data {
+		if d.isStr {
+			b = append(b, d.str...)
+			// Strings in dw are padded to a whole number of words
+			for n := len(d.str); n%s.width != 0; n++ {
+				b = append(b, 0)
+			}
+			continue
+		}
+		v, err := d.x.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		if v, err = value("value", v, s.width, false, !e.final); err != nil {
+			return nil, err
+		}
+		b = appendValue(b, v, s.width)
+	}
+	return b, nil
+}
+
+// parse parses the source into statements.
+func parse(src string) ([]*statement, error) {
+	var stmts []*statement
+	labels := map[string]int{}
+	scope := ""
+	for i, line := range strings.Split(src, "\n") {
+		s, err := parseLine(stripComment(line), &scope)
+		if err != nil {
+			return nil, &Error{Line: i + 1, Err: err}
+		}
+		if s == nil {
+			continue
+		}
+		s.line = i + 1
+		if s.label != "" {
+			if prev, ok := labels[s.label]; ok {
+				return nil, &Error{Line: i + 1, Err: fmt.Errorf("label %q already defined on line %d", s.label, prev)}
+			}
+			labels[s.label] = i + 1
+		}
+		stmts = append(stmts, s)
+	}
+	return stmts, nil
+}
+
+// parseLine parses a line into a statement, or nil if there's nothing on it
+// that needs assembling. scope is the last global label, and is updated if
+// the line defines a new one.
+func parseLine(line string, scope *string) (*statement, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		// Primitive directive, e.g. [bits 16]
+		line = strings.TrimSpace(line[1 : len(line)-1])
+	}
+	toks, err := tokenize(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, nil
+	}
+
+	s := &statement{}
+	p := &parser{toks: toks, scope: *scope}
+
+	// Label, either followed by a colon, or alone on the line
+	if t := toks[0]; t.kind == tokIdent && !isKeyword(t.text) {
+		_, isInst := mnemonic(t.text)
+		_, isPrefix := prefixes[strings.ToLower(t.text)]
+		next, _ := (&parser{toks: toks, pos: 1}).peek()
+		switch {
+		case next.is(":"):
+			p.pos = 2
+		case next.kind == tokIdent && strings.EqualFold(next.text, "equ"):
+			p.pos = 1
+		case len(toks) == 1 && !isInst && !isPrefix && !isDirective(t.text):
+			p.pos = 1
+		}
+		if p.pos > 0 {
+			if !strings.HasPrefix(t.text, ".") {
+				*scope = t.text
+				p.scope = t.text
+			}
+			s.label = p.qualify(t.text)
+		}
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		if s.label == "" {
+			return nil, nil
+		}
+		return s, nil
+	}
+
+	if t.kind == tokIdent && strings.EqualFold(t.text, "times") {
+		p.pos++
+		if s.times, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		t, ok = p.peek()
+		if !ok || t.kind != tokIdent {
+			break
+		}
+		f, isPrefix := prefixes[strings.ToLower(t.text)]
+		if !isPrefix {
+			break
+		}
+		p.pos++
+		if s.flags&f != 0 {
+			return nil, fmt.Errorf("duplicate prefix %s", t.text)
+		}
+		s.flags |= f
+	}
+	if !ok {
+		// Just prefixes
+		return s, nil
+	}
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("expected instruction, found %q", t.text)
+	}
+	p.pos++
+	name := strings.ToLower(t.text)
+
+	switch name {
+	case "equ":
+		if s.label == "" {
+			return nil, errors.New("equ without a label")
+		}
+		s.kind = statementEqu
+		return s, p.rest(&s.value)
+	case "org":
+		if s.label != "" || s.times != nil {
+			return nil, errors.New("org can't have a label or times")
+		}
+		s.kind = statementOrg
+		return s, p.rest(&s.value)
+	case "bits":
+		var x expr
+		if err := p.rest(&x); err != nil {
+			return nil, err
+		}
+		if n, err := x.eval(&env{final: true}); err != nil || n != 16 {
+			return nil, errors.New("only bits 16 is supported")
+		}
+		if s.label == "" {
+			return nil, nil
+		}
+		return s, nil
+	case "db", "dw":
+		s.kind = statementData
+		s.width = 1
+		if name == "dw" {
+			s.width = 2
+		}
+		for _, toks := range splitOperands(p.toks[p.pos:]) {
+			if len(toks) == 1 && toks[0].kind == tokString {
+				s.data = append(s.data, dataItem{str: toks[0].text, isStr: true})
+				continue
+			}
+			sub := &parser{toks: toks, scope: p.scope}
+			var d dataItem
+			if err := sub.rest(&d.x); err != nil {
+				return nil, err
+			}
+			s.data = append(s.data, d)
+		}
+		if len(s.data) == 0 {
+			return nil, fmt.Errorf("%s needs a value", name)
+		}
+		return s, nil
+	}
+
+	if _, ok := mnemonic(name); !ok {
+		return nil, fmt.Errorf("unknown instruction %q", t.text)
+	}
+	s.name = name
+	for _, toks := range splitOperands(p.toks[p.pos:]) {
+		sub := &parser{toks: toks, scope: p.scope}
+		o, err := sub.operand()
+		if err != nil {
+			return nil, err
+		}
+		if !sub.done() {
+			return nil, fmt.Errorf("unexpected %q after operand", sub.toks[sub.pos].text)
+		}
+		s.operands = append(s.operands, o)
+	}
+	return s, nil
+}
+
+// rest parses the rest of the tokens as an expression.
+func (p *parser) rest(x *expr) error {
+	var err error
+	if *x, err = p.expr(); err != nil {
+		return err
+	}
+	if !p.done() {
+		return fmt.Errorf("unexpected %q after expression", p.toks[p.pos].text)
+	}
+	return nil
+}
+
+func isDirective(s string) bool {
+	switch strings.ToLower(s) {
+	case "org", "bits", "db", "dw", "times", "equ":
+		return true
+	}
+	return false
+}
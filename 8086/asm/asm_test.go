@@ -0,0 +1,180 @@
+package asm
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"8086/decoder"
+)
+
+func TestAssemble(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"mov si, bx", "89de"},
+		{"mov cl, 12", "b10c"},
+		{"mov cx, -12", "b9f4ff"},
+		{"mov al, [bx + si]", "8a00"},
+		{"mov dx, [bp]", "8b5600"},
+		{"mov al, [bx + si + 4999]", "8a808713"},
+		{"mov [si - 300], cx", "898cd4fe"},
+		{"mov [bp + di], byte 7", "c60307"},
+		{"mov [di + 901], word 347", "c78585035b01"},
+		{"mov bp, [5]", "8b2e0500"},
+		{"mov ax, [16]", "a11000"},
+		{"mov [15], ax", "a30f00"},
+		{"mov ds, ax", "8ed8"},
+		{"mov [bx], es", "8c07"},
+		{"add ax, 5", "83c005"},
+		{"add al, 5", "0405"},
+		{"add bl, 5", "80c305"},
+		{"add ax, 1000", "05e803"},
+		{"add ax, byte -1", "83c0ff"},
+		{"add ax, 65535", "83c0ff"},
+		{"add word [bx], 300", "81072c01"},
+		{"and ax, 5", "250500"},
+		{"xchg ax, dx", "92"},
+		{"xchg dx, ax", "92"},
+		{"xchg al, [100]", "86066400"},
+		{"xchg [100], al", "86066400"},
+		{"test [bx], al", "8407"},
+		{"test al, [bx]", "8407"},
+		{"inc ax", "40"},
+		{"inc al", "fec0"},
+		{"push word [bp + si]", "ff32"},
+		{"push cs", "0e"},
+		{"pop word [3]", "8f060300"},
+		{"in al, dx", "ec"},
+		{"in ax, 200", "e5c8"},
+		{"out 44, ax", "e72c"},
+		{"lea di, [bx + si - 7]", "8d78f9"},
+		{"shl ah, 1", "d0e4"},
+		{"rcr word [bx], cl", "d31f"},
+		{"sal ax, 1", "d1e0"},
+		{"aam", "d40a"},
+		{"int 13", "cd0d"},
+		{"int3", "cc"},
+		{"ret -7", "c2f9ff"},
+		{"retf", "cb"},
+		{"jmp $+2", "eb00"},
+		{"jmp near $+1000", "e9e503"},
+		{"jmp short $-10", "ebf4"},
+		{"call $+1000", "e8e503"},
+		{"call 4660:22136", "9a78563412"},
+		{"jmp far [bx + di + 300]", "ffa92c01"},
+		{"call [bp - 100]", "ff569c"},
+		{"jz $", "74fe"},
+		{"jnle $", "7ffe"},
+		{"loope $", "e1fe"},
+		{"rep movsb", "f3a4"},
+		{"repne scasb", "f2ae"},
+		{"es movsb", "26a4"},
+		{"rep cs movsw", "f32ea5"},
+		{"cs xlat", "2ed7"},
+		{"lock not byte CS:[bp + 9905]", "f02ef696b126"},
+		{"mov cx, es:[4384]", "268b0e2011"},
+		{"mov cx, [es:4384]", "268b0e2011"},
+		{"sbb word cs:[bx + si - 4332], 10328", "2e819814ef5828"},
+
+		// Labels and directives
+		{"label:\njmp label", "ebfe"},
+		{"jmp label\nlabel:", "eb00"},
+		{"jmp label\ntimes 200 nop\nlabel:", "e9c800" + strings.Repeat("90", 200)},
+		{"je label\nlabel", "7400"},
+		{"org 0x100\nmov ax, $\nmov ax, $ - $$", "b80001b80300"},
+		{"x equ 5\nmov ax, x * 2 + 1", "b80b00"},
+		{"mov ax, x\nx equ 0x10", "b81000"},
+		{"x equ y + 1\ny equ 2\nmov ax, x", "b80300"},
+		{"db 1, -1, 'ab', \"c\"\ndw 0x1234, 'a'", "01ff616263" + "3412" + "6100"},
+		{"times 3 db 7", "070707"},
+		{"times 2 jmp $", "ebfeebfe"},
+		{"bits 16\n[bits 16]\nnop ; comment ; more", "90"},
+		{"a:\n.l: jmp .l\nb:\n.l: jmp .l\njmp a.l", "ebfeebfeebfa"},
+		{"mov ax, 0x10 + 10h + 0b11 + 11b + 017o + 'a'", "b89600"},
+		{"mov ax, (1 << 4) | 3 & ~1", "b81200"},
+	} {
+		got, err := Assemble(tc.src)
+		if err != nil {
+			t.Errorf("%q: %v", tc.src, err)
+			continue
+		}
+		want, _ := hex.DecodeString(tc.want)
+		if string(got) != string(want) {
+			t.Errorf("%q: got % x, want % x", tc.src, got, want)
+		}
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		line int
+		want string
+	}{
+		{"mov ax, bl", 1, "invalid combination"},
+		{"nop\nmov [bx], 5", 2, "operation size not specified"},
+		{"mov al, 256", 1, "out of range"},
+		{"je label\ntimes 200 nop\nlabel:", 1, "jump 200 out of range"},
+		{"jmp nowhere", 1, "undefined symbol"},
+		{"foo ax", 1, "unknown instruction"},
+		{"a:\na:", 2, "already defined"},
+		{"lock mov ax, bx", 1, "can't be locked"},
+		{"mov ax, [bx + bp]", 1, "invalid effective address"},
+		{"es mov ax, ds:[bx]", 1, "conflicting segment"},
+		{"bits 32", 1, "bits 16"},
+		{"shl ax, 2", 1, "invalid combination"},
+		{"times -1 nop", 1, "negative"},
+		{"times 99999999999 db 0", 1, "past 64 KiB"},
+		{"times 0x8000 nop\ntimes 0x8001 nop", 2, "past 64 KiB"},
+		{"x equ x", 1, "unresolved or circular"},
+		{"x equ y\ny equ x", 1, "unresolved or circular"},
+	} {
+		_, err := Assemble(tc.src)
+		var ae *Error
+		if !errors.As(err, &ae) {
+			t.Errorf("%q: got error %v, want *Error", tc.src, err)
+			continue
+		}
+		if ae.Line != tc.line || !strings.Contains(ae.Err.Error(), tc.want) {
+			t.Errorf("%q: got %v, want line %d: %s", tc.src, err, tc.line, tc.want)
+		}
+	}
+}
+
+// TestEncodeInstruction checks every instruction decoded from two bytes
+// followed by some data encodes back to bytes that decode the same.
+func TestEncodeInstruction(t *testing.T) {
+	for b1 := 0; b1 < 256; b1++ {
+		for b2 := 0; b2 < 256; b2++ {
+			b := []byte{byte(b1), byte(b2), 0x34, 0x12, 0x78, 0x56}
+			in, n, err := decoder.Decode(b)
+			if err != nil {
+				continue
+			}
+			enc, err := EncodeInstruction(in)
+			if err != nil {
+				t.Fatalf("% x (%s): %v", b[:n], in, err)
+			}
+			got, _, err := decoder.Decode(enc)
+			if err != nil || !sameInstruction(got, in) {
+				t.Fatalf("% x (%s) encoded as % x (%s, %v)", b[:n], in, enc, got, err)
+			}
+		}
+	}
+}
+
+// sameInstruction reports whether a and b do the same thing, allowing the
+// operands of xchg and test to be either way round.
+func sameInstruction(a, b decoder.Instruction) bool {
+	if a.String() == b.String() {
+		return true
+	}
+	ao, bo := a.Operands(), b.Operands()
+	if !commutative[a.Name] || a.Name != b.Name || a.Flags != b.Flags || len(ao) != 2 || len(bo) != 2 {
+		return false
+	}
+	return ao[0] == bo[1] && ao[1] == bo[0]
+}
@@ -0,0 +1,631 @@
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"8086/decoder"
+)
+
+// operand is an operand with its expressions evaluated.
+type operand struct {
+	kind decoder.OperandKind // Register, Segment, Memory, Immediate or FarPointer
+
+	reg decoder.Register
+	ea  decoder.EffectiveAddress
+	imm int // Immediate value, jump target, or the offset of a far pointer
+	seg int // Segment of a far pointer
+
+	size  int // 1 or 2 if byte or word was given
+	short bool
+	near  bool
+	far   bool
+}
+
+// instruction is an instruction ready to be encoded.
+type instruction struct {
+	name     string
+	flags    decoder.InstructionFlags
+	operands []operand
+}
+
+// aliases maps the other names nasm accepts for an instruction to the name
+// used in the encoding table.
+var aliases = map[string]string{
+	"jz":     "je",
+	"jnz":    "jne",
+	"jc":     "jb",
+	"jnae":   "jb",
+	"jnc":    "jnb",
+	"jae":    "jnb",
+	"jna":    "jbe",
+	"ja":     "jnbe",
+	"jpe":    "jp",
+	"jpo":    "jnp",
+	"jnge":   "jl",
+	"jnl":    "jge",
+	"jng":    "jle",
+	"jnle":   "jg",
+	"loope":  "loopz",
+	"loopne": "loopnz",
+	"sal":    "shl",
+	"xlatb":  "xlat",
+}
+
+// commutative are the instructions whose two operands can be written either
+// way round, though the encoding table only has one order.
+var commutative = map[string]bool{
+	"xchg": true,
+	"test": true,
+}
+
+// prefixes maps the prefix words to the flag they set.
+var prefixes = map[string]decoder.InstructionFlags{
+	"rep":   decoder.FlagRepeatZ,
+	"repe":  decoder.FlagRepeatZ,
+	"repz":  decoder.FlagRepeatZ,
+	"repne": decoder.FlagRepeat,
+	"repnz": decoder.FlagRepeat,
+	"lock":  decoder.FlagLock,
+	"es":    decoder.FlagESOverride,
+	"cs":    decoder.FlagCSOverride,
+	"ss":    decoder.FlagSSOverride,
+	"ds":    decoder.FlagDSOverride,
+}
+
+const flagsSegment = decoder.FlagESOverride | decoder.FlagCSOverride |
+	decoder.FlagSSOverride | decoder.FlagDSOverride
+
+var segmentFlags = map[decoder.Register]decoder.InstructionFlags{
+	decoder.ES: decoder.FlagESOverride,
+	decoder.CS: decoder.FlagCSOverride,
+	decoder.SS: decoder.FlagSSOverride,
+	decoder.DS: decoder.FlagDSOverride,
+}
+
+// encodings holds the encodings for each instruction name, in table order.
+var encodings = func() map[string][]*decoder.Encoding {
+	m := map[string][]*decoder.Encoding{}
+	for _, enc := range decoder.Encodings() {
+		m[enc.Name] = append(m[enc.Name], enc)
+	}
+	return m
+}()
+
+// mnemonic returns the table name for name, and whether it's an instruction.
+func mnemonic(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	_, ok := encodings[name]
+	return name, ok || name == "nop"
+}
+
+// regField returns the value of a REG or RM field for the register r.
+func regField(r decoder.Register) int {
+	if r.High() {
+		return r.Index() + 4
+	}
+	return r.Index()
+}
+
+var (
+	errOperands = errors.New("invalid combination of opcode and operands")
+	errNoSize   = errors.New("operation size not specified")
+)
+
+// rangeError is returned when a value doesn't fit in its field. It's only
+// reported if no other encoding fits.
+type rangeError struct {
+	what  string
+	value int
+}
+
+func (e *rangeError) Error() string {
+	return fmt.Sprintf("%s %d out of range", e.what, e.value)
+}
+
+// EncodeInstruction encodes a decoded instruction, at its offset, in the
+// shortest way the assembler knows. This isn't necessarily the same bytes it
+// was decoded from, but will decode to the same instruction.
+func EncodeInstruction(in decoder.Instruction) ([]byte, error) {
+	inst := instruction{name: in.Name, flags: in.Flags}
+	for _, o := range in.Operands() {
+		op := operand{kind: o.Kind, reg: o.Reg}
+		switch o.Kind {
+		case decoder.OperandMemory:
+			op.ea = o.EA
+			op.far = o.Far
+			if !o.Far {
+				op.size = int(in.W) + 1
+			}
+		case decoder.OperandImmediate:
+			op.imm = int(o.Imm)
+		case decoder.OperandRelative:
+			op.kind = decoder.OperandImmediate
+			op.imm = in.Target(o)
+			op.near = o.Near
+		case decoder.OperandFarPointer:
+			op.imm = int(o.Imm)
+			op.seg = int(o.Segment)
+		}
+		inst.operands = append(inst.operands, op)
+	}
+	return encode(inst, in.Offset, 0, false)
+}
+
+// encode returns the shortest encoding of in at addr that's at least minLen
+// bytes, or the longest if none are. If lax is set values that are out of
+// range are truncated rather than rejected, for when labels aren't known yet.
+func encode(in instruction, addr, minLen int, lax bool) ([]byte, error) {
+	pre, err := prefixBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	if in.name == "" {
+		return pre, nil
+	}
+	name, ok := mnemonic(in.name)
+	if !ok {
+		return nil, fmt.Errorf("unknown instruction %q", in.name)
+	}
+	if name == "nop" {
+		// nop isn't in the table, as it's xchg ax, ax
+		if len(in.operands) != 0 {
+			return nil, errOperands
+		}
+		return append(pre, 0x90), nil
+	}
+
+	type candidate struct {
+		b     []byte
+		order int
+	}
+	var found []candidate
+	var firstErr error
+	fail := func(err error) {
+		var re *rangeError
+		// A value out of range is a better explanation than the operands not
+		// matching, as the operands did match.
+		if firstErr == nil || (errors.As(err, &re) && !errors.As(firstErr, &re)) ||
+			(err == errNoSize && firstErr == errOperands) {
+			firstErr = err
+		}
+	}
+
+	orders := []instruction{in}
+	if commutative[name] && len(in.operands) == 2 {
+		swapped := in
+		swapped.operands = []operand{in.operands[1], in.operands[0]}
+		orders = append(orders, swapped)
+	}
+
+	try := func(lax bool) {
+		order := 0
+		for _, in := range orders {
+			for _, enc := range encodings[name] {
+				for _, c := range choices(enc, in) {
+					order++
+					b, err := encodeWith(enc, in, c, pre, addr, lax)
+					if err != nil {
+						fail(err)
+						continue
+					}
+					found = append(found, candidate{b, order})
+				}
+			}
+		}
+	}
+	try(lax)
+	if len(found) == 0 {
+		if firstErr == nil {
+			firstErr = errOperands
+		}
+		return nil, firstErr
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if len(found[i].b) != len(found[j].b) {
+			return len(found[i].b) < len(found[j].b)
+		}
+		return found[i].order < found[j].order
+	})
+	for _, c := range found {
+		if len(c.b) >= minLen {
+			return c.b, nil
+		}
+	}
+	return found[len(found)-1].b, nil
+}
+
+// prefixBytes returns the prefixes of in in the order nasm writes them.
+func prefixBytes(in instruction) ([]byte, error) {
+	flags := in.flags
+	for _, o := range in.operands {
+		if o.kind != decoder.OperandMemory || o.ea.Segment == decoder.RegNone {
+			continue
+		}
+		f := segmentFlags[o.ea.Segment]
+		if flags&flagsSegment&^f != 0 {
+			return nil, errors.New("conflicting segment overrides")
+		}
+		flags |= f
+	}
+
+	var b []byte
+	for _, p := range []struct {
+		flag decoder.InstructionFlags
+		b    byte
+	}{
+		{decoder.FlagRepeatZ, 0xF3},
+		{decoder.FlagRepeat, 0xF2},
+		{decoder.FlagLock, 0xF0},
+		{decoder.FlagESOverride, 0x26},
+		{decoder.FlagCSOverride, 0x2E},
+		{decoder.FlagSSOverride, 0x36},
+		{decoder.FlagDSOverride, 0x3E},
+	} {
+		if flags&p.flag != 0 {
+			b = append(b, p.b)
+		}
+	}
+	if flags&decoder.FlagRepeat != 0 && flags&decoder.FlagRepeatZ != 0 {
+		return nil, errors.New("conflicting repeat prefixes")
+	}
+	return b, nil
+}
+
+// choice is one way of filling in the fields an encoding leaves open.
+type choice struct {
+	d, s int
+	mod  int // MOD for a memory operand, or -1 if there isn't one or it's direct
+}
+
+// choices returns the choices to try for enc, preferring D=0 then S=0.
+func choices(enc *decoder.Encoding, in instruction) []choice {
+	ds, ss, mods := []int{0}, []int{0}, []int{-1}
+	if hasField(enc, "D") {
+		ds = []int{0, 1}
+	}
+	if hasField(enc, "S") {
+		ss = []int{0, 1}
+	}
+	for _, o := range in.operands {
+		if o.kind == decoder.OperandMemory && !o.ea.Direct() {
+			mods = []int{0b00, 0b01, 0b10}
+		}
+	}
+	var cs []choice
+	for _, d := range ds {
+		for _, s := range ss {
+			for _, mod := range mods {
+				cs = append(cs, choice{d, s, mod})
+			}
+		}
+	}
+	return cs
+}
+
+func hasField(enc *decoder.Encoding, name string) bool {
+	for _, b := range enc.Bytes {
+		for _, p := range b {
+			if p.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encodeWith encodes in with enc and the choice c, after the prefixes pre.
+func encodeWith(enc *decoder.Encoding, in instruction, c choice, pre []byte, addr int, lax bool) ([]byte, error) {
+	var types []string
+	if enc.Type != "" {
+		types = strings.Split(enc.Type, "__")
+	}
+	if len(types) != len(in.operands) {
+		return nil, errOperands
+	}
+	if c.d == 1 {
+		types[0], types[1] = types[1], types[0]
+	}
+
+	// Without a W field the instruction operates on words
+	fields := map[string]int{"D": c.d, "S": c.s, "W": 1}
+	var (
+		width, immSize int
+		disp, dispLen  int
+		data           int
+		jump, far      *operand
+	)
+	setWidth := func(w int) bool {
+		if width != 0 && width != w {
+			return false
+		}
+		width = w
+		return true
+	}
+
+	for i, typ := range types {
+		o := in.operands[i]
+		switch typ {
+		case "REG":
+			if o.kind != decoder.OperandRegister || !setWidth(o.reg.Width()) {
+				return nil, errOperands
+			}
+			fields["REG"] = regField(o.reg)
+		case "RM", "FARRM":
+			switch {
+			case typ == "RM" && o.kind == decoder.OperandRegister:
+				if !setWidth(o.reg.Width()) {
+					return nil, errOperands
+				}
+				fields["MOD"], fields["RM"] = 0b11, regField(o.reg)
+			case o.kind == decoder.OperandMemory && o.far == (typ == "FARRM"):
+				if o.size != 0 && !setWidth(o.size) {
+					return nil, errOperands
+				}
+				mod, rm, ok := modRM(o.ea, c.mod)
+				if !ok {
+					return nil, errOperands
+				}
+				fields["MOD"], fields["RM"] = mod, rm
+				disp = int(o.ea.Displacement)
+				switch {
+				case mod == 0b01:
+					dispLen = 1
+				case mod == 0b10, mod == 0b00 && rm == 0b110:
+					dispLen = 2
+				}
+			default:
+				return nil, errOperands
+			}
+		case "IMM":
+			if o.kind != decoder.OperandImmediate || o.short || o.near || o.far {
+				return nil, errOperands
+			}
+			immSize, data = o.size, o.imm
+		case "DATA":
+			if o.kind != decoder.OperandImmediate || o.short || o.near || o.far {
+				return nil, errOperands
+			}
+			data = o.imm
+		case "JUMP", "NEAR":
+			if o.kind != decoder.OperandImmediate || o.far || o.size != 0 ||
+				(typ == "JUMP" && o.near) || (typ == "NEAR" && o.short) {
+				return nil, errOperands
+			}
+			jump = &in.operands[i]
+		case "FAR":
+			if o.kind != decoder.OperandFarPointer {
+				return nil, errOperands
+			}
+			far = &in.operands[i]
+		case "MEM":
+			if o.kind != decoder.OperandMemory || o.far || !o.ea.Direct() ||
+				(o.size != 0 && !setWidth(o.size)) {
+				return nil, errOperands
+			}
+			data = int(uint16(o.ea.Displacement))
+		case "ACC":
+			if o.kind != decoder.OperandRegister || o.reg.Index() != 0 || o.reg.High() ||
+				!setWidth(o.reg.Width()) {
+				return nil, errOperands
+			}
+		case "DX":
+			if o.kind != decoder.OperandRegister || o.reg != decoder.DX {
+				return nil, errOperands
+			}
+		case "SR":
+			if o.kind != decoder.OperandSegment {
+				return nil, errOperands
+			}
+			fields["SR"] = o.reg.Index() - 8
+		case "V":
+			switch {
+			case o.kind == decoder.OperandRegister && o.reg == decoder.CL:
+				fields["V"] = 1
+			case o.kind == decoder.OperandImmediate && (o.imm == 1 || lax) && o.size == 0:
+				fields["V"] = 0
+			default:
+				return nil, errOperands
+			}
+		default:
+			return nil, errOperands
+		}
+	}
+
+	// The size of an immediate decides the width if nothing else does, and
+	// a byte immediate in a word operation has to be sign extended
+	switch {
+	case immSize == 0:
+	case width == 0:
+		width = immSize
+	case width == 1 && immSize == 2:
+		return nil, errOperands
+	case width == 2 && immSize == 1 && c.s == 0:
+		return nil, errOperands
+	}
+
+	if hasField(enc, "W") {
+		if width == 0 {
+			return nil, errNoSize
+		}
+		fields["W"] = width - 1
+	} else if width == 1 {
+		return nil, errOperands
+	}
+	if c.s == 1 && fields["W"] == 0 {
+		// 0x82 is the same as 0x80, and nasm never uses it
+		return nil, errOperands
+	}
+
+	var b []byte
+	b = append(b, pre...)
+	jumpAt, jumpLen := -1, 0
+	for _, parts := range enc.Bytes {
+		if len(parts) == 1 && !parts[0].IsConst && parts[0].Len == 8 {
+			switch parts[0].Name {
+			case "DISP":
+				if dispLen == 0 {
+					continue
+				}
+				v, err := value("displacement", disp, dispLen, false, lax)
+				if err != nil {
+					return nil, err
+				}
+				b = appendValue(b, v, dispLen)
+			case "DATA":
+				v, err := value("immediate", data, 1, false, lax)
+				if err != nil {
+					return nil, err
+				}
+				b = appendValue(b, v, 1)
+			case "DATAW":
+				n, signed := 1, false
+				switch {
+				case fields["W"] == 1 && c.s == 0:
+					n = 2
+				case fields["W"] == 1:
+					signed = true
+				}
+				v, err := value("immediate", data, n, signed, lax)
+				if err != nil {
+					return nil, err
+				}
+				b = appendValue(b, v, n)
+			case "ADDR":
+				b = appendValue(b, data, 2)
+			case "JUMP":
+				jumpAt, jumpLen = len(b), 1
+				b = append(b, 0)
+			case "JUMPW":
+				jumpAt, jumpLen = len(b), 2
+				b = append(b, 0, 0)
+			case "FAR":
+				off, err := value("offset", far.imm, 2, false, lax)
+				if err != nil {
+					return nil, err
+				}
+				seg, err := value("segment", far.seg, 2, false, lax)
+				if err != nil {
+					return nil, err
+				}
+				b = appendValue(b, off, 2)
+				b = appendValue(b, seg, 2)
+			default:
+				return nil, fmt.Errorf("unknown field %s in %q", parts[0].Name, enc.Orig)
+			}
+			continue
+		}
+
+		var v byte
+		for _, p := range parts {
+			f := p.Const
+			if !p.IsConst {
+				f = byte(fields[p.Name])
+			}
+			v |= (f & (1<<p.Len - 1)) << (8 - p.Start - p.Len)
+		}
+		b = append(b, v)
+	}
+
+	if jumpAt >= 0 {
+		rel := jump.imm - (addr + len(b))
+		if jumpLen == 2 {
+			// A near jump wraps around the segment, so can reach anywhere
+			rel = int(int16(rel))
+		}
+		v, err := value("jump", rel, jumpLen, true, lax)
+		if err != nil {
+			return nil, err
+		}
+		appendValue(b[:jumpAt], v, jumpLen)
+	}
+
+	// Check it decodes back to the same encoding, as one encoding can hide
+	// another, e.g. test with D set is xchg.
+	got, n, err := decoder.Decode(b)
+	if err != nil {
+		var de *decoder.DecodeError
+		if errors.As(err, &de) && de.Reason == decoder.ReasonInvalidLock {
+			return nil, errors.New("instruction can't be locked")
+		}
+		return nil, errOperands
+	}
+	if n != len(b) || got.Encoding != enc {
+		return nil, errOperands
+	}
+	return b, nil
+}
+
+// modRM returns the MOD and RM fields for a memory operand, and whether the
+// address can be encoded with mod, which is -1 for a direct address.
+func modRM(ea decoder.EffectiveAddress, mod int) (int, int, bool) {
+	if ea.Direct() {
+		return 0b00, 0b110, mod == -1
+	}
+
+	var rm int
+	switch {
+	case ea.Base == decoder.BX && ea.Index == decoder.SI:
+		rm = 0b000
+	case ea.Base == decoder.BX && ea.Index == decoder.DI:
+		rm = 0b001
+	case ea.Base == decoder.BP && ea.Index == decoder.SI:
+		rm = 0b010
+	case ea.Base == decoder.BP && ea.Index == decoder.DI:
+		rm = 0b011
+	case ea.Base == decoder.RegNone && ea.Index == decoder.SI:
+		rm = 0b100
+	case ea.Base == decoder.RegNone && ea.Index == decoder.DI:
+		rm = 0b101
+	case ea.Base == decoder.BP && ea.Index == decoder.RegNone:
+		rm = 0b110
+	case ea.Base == decoder.BX && ea.Index == decoder.RegNone:
+		rm = 0b111
+	default:
+		return 0, 0, false
+	}
+
+	switch mod {
+	case 0b00:
+		// [bp] with no displacement is the direct address
+		return mod, rm, ea.Displacement == 0 && rm != 0b110
+	case 0b01:
+		return mod, rm, ea.Displacement >= -128 && ea.Displacement <= 127
+	case 0b10:
+		return mod, rm, true
+	}
+	return 0, 0, false
+}
+
+// value checks v fits in n bytes, as a signed value if signed is set or
+// either signed or unsigned otherwise.
+func value(what string, v, n int, signed, lax bool) (int, error) {
+	lo, hi := -1<<(8*n-1), 1<<(8*n)-1
+	if signed {
+		hi = 1<<(8*n-1) - 1
+		if n == 1 {
+			// A sign extended byte can also be written as the 16-bit value it
+			// extends to, e.g. 65535 for -1.
+			if v >= 0xFF80 && v <= 0xFFFF {
+				v -= 0x10000
+			}
+		}
+	}
+	if !lax && (v < lo || v > hi) {
+		return 0, &rangeError{what, v}
+	}
+	return v, nil
+}
+
+func appendValue(b []byte, v, n int) []byte {
+	for i := 0; i < n; i++ {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
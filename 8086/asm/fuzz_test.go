@@ -0,0 +1,86 @@
+package asm
+
+import (
+	"bytes"
+	"testing"
+
+	"8086/decoder"
+)
+
+// FuzzRoundTrip decodes random bytes and checks every instruction encodes back
+// to the same bytes, or to bytes that are no longer and decode to the same
+// instruction. Failures found by go test -fuzz are saved under testdata/fuzz
+// and rerun by go test.
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range []string{
+		"\x89\xde",                 // mov si, bx
+		"\x8b\xf3",                 // mov si, bx, with D set
+		"\x83\xc0\xff",             // add ax, 65535
+		"\x82\xc3\x05",             // add bl, 5, with S set
+		"\x81\xc3\x05\x00",         // add bx, 5, with a word immediate
+		"\x8b\x80\x05\x00",         // mov ax, [bx + si + 5], with a word displacement
+		"\xff\xc0",                 // inc ax
+		"\x87\xc8",                 // xchg cx, ax
+		"\x86\x06\x64\x00",         // xchg al, [100]
+		"\xa1\x10\x00",             // mov ax, [16]
+		"\x2e\xf3\xa5",             // rep cs movsw
+		"\xf0\x2e\xf6\x96\xb1\x26", // lock not byte cs:[bp + 9905]
+		"\xe9\x00\x00",             // jmp near $+3
+		"\x7f\xfe",                 // jg $
+		"\x9a\x78\x56\x34\x12",     // call 4660:22136
+		"\xff\x1f",                 // call far [bx]
+		"\xd4\x0a",                 // aam
+		"\x0f\xd6\xf3\xf3",         // pop cs, undefined, duplicate prefix
+		"\x26\x8b\x07",             // mov ax, es:[bx]
+		"\x36\xa4",                 // ss movsb
+		"\x3e\x8a\x46\x02",         // mov al, ds:[bp + 2]
+		"\xf2\xae",                 // repne scasb
+		"\xf3\xa6",                 // repe cmpsb
+		"\xf0\x87\x07",             // lock xchg ax, [bx]
+		"\xea\x78\x56\x34\x12",     // jmp 4660:22136
+		"\xff\x2f",                 // jmp far [bx]
+		"\xff\x9e\x00\x10",         // call far [bp + 4096]
+		"\xe8\xfd\xff",             // call near $+0
+		"\xcb",                     // retf
+		"\xca\x04\x00",             // retf 4
+	} {
+		f.Add([]byte(seed))
+	}
+	// mov ax, r/m with every ModRM byte, for every mode, register and size of
+	// displacement
+	for modRM := 0; modRM < 256; modRM++ {
+		f.Add([]byte{0x8b, byte(modRM), 0x34, 0x12})
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		it := decoder.NewIterator(data)
+		for !it.Done() {
+			start := it.Offset()
+			in, err := it.Next()
+			if err != nil {
+				it.Seek(start + 1)
+				continue
+			}
+			orig := data[start : start+in.Length]
+
+			b, err := EncodeInstruction(in)
+			if err != nil {
+				t.Fatalf("% x (%s) at %d: %v", orig, in, start, err)
+			}
+			if bytes.Equal(b, orig) {
+				continue
+			}
+			if len(b) > len(orig) {
+				t.Fatalf("% x (%s) encoded as longer % x", orig, in, b)
+			}
+			got, _, err := decoder.Decode(b)
+			if err != nil {
+				t.Fatalf("% x (%s) encoded as % x, which doesn't decode: %v", orig, in, b, err)
+			}
+			got.Offset = in.Offset
+			if !sameInstruction(got, in) {
+				t.Fatalf("% x (%s) encoded as % x (%s)", orig, in, b, got)
+			}
+		}
+	})
+}
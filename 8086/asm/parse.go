@@ -0,0 +1,595 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"8086/decoder"
+)
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  int
+}
+
+func (t token) is(punct string) bool {
+	return t.kind == tokPunct && t.text == punct
+}
+
+// tokenize splits a line, with the comment already removed, into tokens.
+func tokenize(line string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '$':
+			if i+1 < len(line) && line[i+1] == '$' {
+				toks = append(toks, token{kind: tokPunct, text: "$$"})
+				i += 2
+			} else {
+				toks = append(toks, token{kind: tokPunct, text: "$"})
+				i++
+			}
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(line) && isIdentChar(line[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: line[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(line) && (isIdentChar(line[j])) {
+				j++
+			}
+			n, err := parseNumber(line[i:j])
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokNumber, text: line[i:j], num: n})
+			i = j
+		case c == '\'' || c == '"' || c == '`':
+			j := strings.IndexByte(line[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			toks = append(toks, token{kind: tokString, text: line[i+1 : i+1+j]})
+			i += j + 2
+		case c == '<' || c == '>':
+			if i+1 >= len(line) || line[i+1] != c {
+				return nil, fmt.Errorf("unexpected %q", c)
+			}
+			toks = append(toks, token{kind: tokPunct, text: line[i : i+2]})
+			i += 2
+		case strings.IndexByte(",:[]+-*/%()|&^~", c) >= 0:
+			toks = append(toks, token{kind: tokPunct, text: string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return toks, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '.' || c == '?' || c == '@' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '$' || c == '#' || c == '~'
+}
+
+// parseNumber parses a nasm numeric constant: decimal, or hex, binary and
+// octal with either a 0x/0b/0o prefix or a h/b/o suffix.
+func parseNumber(s string) (int, error) {
+	l := strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	base := 10
+	switch {
+	case strings.HasPrefix(l, "0x") || strings.HasPrefix(l, "0h"):
+		base, l = 16, l[2:]
+	case strings.HasSuffix(l, "h"):
+		base, l = 16, l[:len(l)-1]
+	case strings.HasPrefix(l, "0b") || strings.HasPrefix(l, "0y"):
+		base, l = 2, l[2:]
+	case strings.HasSuffix(l, "b") || strings.HasSuffix(l, "y"):
+		base, l = 2, l[:len(l)-1]
+	case strings.HasPrefix(l, "0o") || strings.HasPrefix(l, "0q"):
+		base, l = 8, l[2:]
+	case strings.HasSuffix(l, "o") || strings.HasSuffix(l, "q"):
+		base, l = 8, l[:len(l)-1]
+	case strings.HasPrefix(l, "0d"):
+		l = l[2:]
+	case strings.HasSuffix(l, "d"):
+		l = l[:len(l)-1]
+	}
+	n, err := strconv.ParseInt(l, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return int(n), nil
+}
+
+// env is what an expression is evaluated in.
+type env struct {
+	symbols map[string]int
+	here    int // $
+	start   int // $$
+
+	// final is set on the last pass, where undefined symbols are an error.
+	// Before that they evaluate to 0 and unresolved is set.
+	final      bool
+	unresolved bool
+}
+
+type expr interface {
+	eval(e *env) (int, error)
+}
+
+type numExpr int
+
+func (n numExpr) eval(*env) (int, error) { return int(n), nil }
+
+type symExpr string
+
+func (s symExpr) eval(e *env) (int, error) {
+	if v, ok := e.symbols[string(s)]; ok {
+		return v, nil
+	}
+	if e.final {
+		return 0, fmt.Errorf("undefined symbol %q", string(s))
+	}
+	e.unresolved = true
+	return 0, nil
+}
+
+type hereExpr struct{}
+
+func (hereExpr) eval(e *env) (int, error) { return e.here, nil }
+
+type startExpr struct{}
+
+func (startExpr) eval(e *env) (int, error) { return e.start, nil }
+
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+func (u unaryExpr) eval(e *env) (int, error) {
+	x, err := u.x.eval(e)
+	if err != nil {
+		return 0, err
+	}
+	switch u.op {
+	case "-":
+		return -x, nil
+	case "~":
+		return ^x, nil
+	}
+	return x, nil
+}
+
+type binaryExpr struct {
+	op   string
+	x, y expr
+}
+
+func (b binaryExpr) eval(e *env) (int, error) {
+	x, err := b.x.eval(e)
+	if err != nil {
+		return 0, err
+	}
+	y, err := b.y.eval(e)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/", "%":
+		if y == 0 {
+			if e.unresolved {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("division by zero")
+		}
+		if b.op == "/" {
+			return x / y, nil
+		}
+		return x % y, nil
+	case "|":
+		return x | y, nil
+	case "&":
+		return x & y, nil
+	case "^":
+		return x ^ y, nil
+	case "<<":
+		return x << uint(y), nil
+	case ">>":
+		return x >> uint(y), nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", b.op)
+}
+
+// binaryPrecedence lists the binary operators from lowest to highest
+// precedence, as in nasm.
+var binaryPrecedence = [][]string{
+	{"|"},
+	{"^"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// parser parses expressions and operands from a slice of tokens.
+type parser struct {
+	toks []token
+	pos  int
+
+	// scope is the last global label, which local labels starting with a
+	// dot belong to
+	scope string
+}
+
+// qualify returns the full name of the symbol name.
+func (p *parser) qualify(name string) string {
+	if strings.HasPrefix(name, ".") {
+		return p.scope + name
+	}
+	return name
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+func (p *parser) peekIs(punct string) bool {
+	t, ok := p.peek()
+	return ok && t.is(punct)
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *parser) expr() (expr, error) {
+	return p.binary(0)
+}
+
+func (p *parser) binary(level int) (expr, error) {
+	if level == len(binaryPrecedence) {
+		return p.unary()
+	}
+	x, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != tokPunct || !contains(binaryPrecedence[level], t.text) {
+			return x, nil
+		}
+		p.pos++
+		y, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = binaryExpr{op: t.text, x: x, y: y}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("expected expression")
+	}
+	p.pos++
+	switch {
+	case t.is("-"), t.is("+"), t.is("~"):
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: t.text, x: x}, nil
+	case t.is("("):
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekIs(")") {
+			return nil, fmt.Errorf("expected )")
+		}
+		p.pos++
+		return x, nil
+	case t.is("$"):
+		return hereExpr{}, nil
+	case t.is("$$"):
+		return startExpr{}, nil
+	case t.kind == tokNumber:
+		return numExpr(t.num), nil
+	case t.kind == tokString:
+		// A character constant, stored little endian
+		n := 0
+		for i := len(t.text) - 1; i >= 0; i-- {
+			n = n<<8 | int(t.text[i])
+		}
+		return numExpr(n), nil
+	case t.kind == tokIdent:
+		if isKeyword(t.text) {
+			return nil, fmt.Errorf("unexpected %q in expression", t.text)
+		}
+		return symExpr(p.qualify(t.text)), nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", t.text)
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// operandExpr is an operand as written, before its expressions are evaluated.
+type operandExpr struct {
+	kind decoder.OperandKind // Register, Segment, Memory, Immediate or FarPointer
+
+	reg decoder.Register
+
+	// Memory
+	base, index decoder.Register
+	disp        expr
+	seg         decoder.Register
+
+	// Immediate value or target, and the offset of a far pointer
+	imm    expr
+	farSeg expr
+
+	size  int // 1 or 2 if byte or word was given
+	short bool
+	near  bool
+	far   bool
+}
+
+var sizeKeywords = map[string]bool{
+	"byte":  true,
+	"word":  true,
+	"short": true,
+	"near":  true,
+	"far":   true,
+}
+
+// isKeyword reports whether s can't be a symbol in an expression.
+func isKeyword(s string) bool {
+	s = strings.ToLower(s)
+	return sizeKeywords[s] || decoder.ParseRegister(s) != decoder.RegNone
+}
+
+func (p *parser) operand() (operandExpr, error) {
+	var op operandExpr
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != tokIdent || !sizeKeywords[strings.ToLower(t.text)] {
+			break
+		}
+		p.pos++
+		switch strings.ToLower(t.text) {
+		case "byte":
+			op.size = 1
+		case "word":
+			op.size = 2
+		case "short":
+			op.short = true
+		case "near":
+			op.near = true
+		case "far":
+			op.far = true
+		}
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		return op, fmt.Errorf("expected operand")
+	}
+
+	if t.kind == tokIdent {
+		if r := decoder.ParseRegister(t.text); r != decoder.RegNone {
+			p.pos++
+			if !p.peekIs(":") {
+				op.reg = r
+				op.kind = decoder.OperandRegister
+				if r.IsSegment() {
+					op.kind = decoder.OperandSegment
+				}
+				return op, nil
+			}
+			// Segment override before the brackets, es:[bx]
+			if !r.IsSegment() {
+				return op, fmt.Errorf("%s isn't a segment register", r)
+			}
+			p.pos++
+			op.seg = r
+			if !p.peekIs("[") {
+				return op, fmt.Errorf("expected [ after %s:", r)
+			}
+		}
+	}
+
+	if p.peekIs("[") {
+		p.pos++
+		if err := p.memory(&op); err != nil {
+			return op, err
+		}
+		op.kind = decoder.OperandMemory
+		return op, nil
+	}
+
+	x, err := p.expr()
+	if err != nil {
+		return op, err
+	}
+	op.kind = decoder.OperandImmediate
+	op.imm = x
+	if p.peekIs(":") {
+		p.pos++
+		off, err := p.expr()
+		if err != nil {
+			return op, err
+		}
+		op.kind = decoder.OperandFarPointer
+		op.farSeg = x
+		op.imm = off
+	}
+	return op, nil
+}
+
+// memory parses the inside of [...] into op, after the [
+func (p *parser) memory(op *operandExpr) error {
+	// Segment override inside the brackets, [es:bx]
+	if t, ok := p.peek(); ok && t.kind == tokIdent && p.pos+1 < len(p.toks) && p.toks[p.pos+1].is(":") {
+		r := decoder.ParseRegister(t.text)
+		if !r.IsSegment() {
+			return fmt.Errorf("%s isn't a segment register", t.text)
+		}
+		if op.seg != decoder.RegNone {
+			return fmt.Errorf("more than one segment override")
+		}
+		op.seg = r
+		p.pos += 2
+	}
+
+	// Split into terms at the top level + and -, pulling out the registers
+	// and summing the rest into the displacement.
+	sign := "+"
+	for {
+		var term []token
+		depth := 0
+		for ; p.pos < len(p.toks); p.pos++ {
+			t := p.toks[p.pos]
+			if depth == 0 && (t.is("]") || ((t.is("+") || t.is("-")) && len(term) > 0 && endsTerm(term[len(term)-1]))) {
+				break
+			}
+			switch {
+			case t.is("("):
+				depth++
+			case t.is(")"):
+				depth--
+			}
+			term = append(term, t)
+		}
+		if len(term) == 0 {
+			return fmt.Errorf("expected address")
+		}
+
+		if len(term) == 1 && term[0].kind == tokIdent && decoder.ParseRegister(term[0].text) != decoder.RegNone {
+			r := decoder.ParseRegister(term[0].text)
+			if sign != "+" {
+				return fmt.Errorf("can't subtract register %s", r)
+			}
+			switch {
+			case (r == decoder.BX || r == decoder.BP) && op.base == decoder.RegNone:
+				op.base = r
+			case (r == decoder.SI || r == decoder.DI) && op.index == decoder.RegNone:
+				op.index = r
+			default:
+				return fmt.Errorf("invalid effective address register %s", r)
+			}
+		} else {
+			sub := &parser{toks: term, scope: p.scope}
+			x, err := sub.expr()
+			if err != nil {
+				return err
+			}
+			if !sub.done() {
+				return fmt.Errorf("unexpected %q in address", sub.toks[sub.pos].text)
+			}
+			if sign == "-" {
+				x = unaryExpr{op: "-", x: x}
+			}
+			if op.disp == nil {
+				op.disp = x
+			} else {
+				op.disp = binaryExpr{op: "+", x: op.disp, y: x}
+			}
+		}
+
+		t, ok := p.peek()
+		if !ok {
+			return fmt.Errorf("expected ]")
+		}
+		p.pos++
+		if t.is("]") {
+			return nil
+		}
+		sign = t.text
+	}
+}
+
+// endsTerm reports whether t can end a term, so a following + or - is binary.
+func endsTerm(t token) bool {
+	return t.kind != tokPunct || t.is(")") || t.is("$") || t.is("$$")
+}
+
+// splitOperands splits tokens at the top level commas.
+func splitOperands(toks []token) [][]token {
+	var ops [][]token
+	depth, start := 0, 0
+	for i, t := range toks {
+		switch {
+		case t.is("[") || t.is("("):
+			depth++
+		case t.is("]") || t.is(")"):
+			depth--
+		case t.is(",") && depth == 0:
+			ops = append(ops, toks[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(toks) {
+		ops = append(ops, toks[start:])
+	}
+	return ops
+}
+
+// stripComment removes a ; comment that isn't inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ';':
+			return line[:i]
+		}
+	}
+	return line
+}
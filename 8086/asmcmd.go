@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"8086/asm"
+)
+
+// asmMain is the asm subcommand, which assembles nasm syntax into a flat
+// binary.
+func asmMain(args []string) int {
+	fs := flag.NewFlagSet("asm", flag.ContinueOnError)
+	input := fs.String("input", "", "assembly file to read")
+	output := fs.String("o", "", "binary file to write, defaults to the input without its extension")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "asm: -input is required")
+		return 2
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(*input, filepath.Ext(*input))
+		if *output == *input {
+			*output += ".bin"
+		}
+	}
+
+	src, err := os.ReadFile(*input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	b, err := asm.Assemble(string(src))
+	if err != nil {
+		var ae *asm.Error
+		if errors.As(err, &ae) {
+			fmt.Fprintf(os.Stderr, "%s:%d: %v\n", *input, ae.Line, ae.Err)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *input, err)
+		}
+		return 1
+	}
+	if err := os.WriteFile(*output, b, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
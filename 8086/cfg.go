@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"8086/decoder"
+)
+
+// Kinds of edge between basic blocks.
+const (
+	edgeTaken       = "taken"
+	edgeFallthrough = "fallthrough"
+	edgeCall        = "call"
+)
+
+// block is a basic block: instructions that always run one after the other,
+// entered only at the first and left only after the last. The JSON schema is
+// documented in schema/cfg.schema.json, keep them in sync.
+type block struct {
+	Start        int               `json:"start"`
+	End          int               `json:"end"`
+	Label        string            `json:"label,omitempty"`
+	Reachable    bool              `json:"reachable"`
+	Instructions []jsonInstruction `json:"instructions"`
+	Edges        []edge            `json:"edges"`
+
+	last decoder.Instruction
+}
+
+type edge struct {
+	To   int    `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// cfgMain is the cfg subcommand, which prints the control flow graph of the
+// input as graphviz DOT or JSON.
+func cfgMain(args []string) int {
+	fs := flag.NewFlagSet("cfg", flag.ContinueOnError)
+	input := fs.String("input", "", "8086 binary file to read")
+	format := fs.String("format", "dot", "output format: dot or json")
+	entry := fs.String("entry", "", "comma separated offsets of extra entry points")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "cfg: -input is required")
+		return 2
+	}
+	if *format != "dot" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "cfg: invalid -format %q: must be dot or json\n", *format)
+		return 2
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	entries, err := parseEntries(*entry, len(data))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cfg: invalid -entry: %v\n", err)
+		return 2
+	}
+
+	blocks := buildCFG(data, entries)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Entries []int   `json:"entries"`
+			Blocks  []block `json:"blocks"`
+		}{entries, blocks}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	writeDOT(os.Stdout, blocks)
+	return 0
+}
+
+// buildCFG splits the input into basic blocks. The code reachable from the
+// entry points is found first, as with -recursive, then whatever decodes in
+// the gaps between it is added as unreachable blocks. Bytes that don't decode
+// aren't part of any block.
+func buildCFG(data []byte, entries []int) []block {
+	code := traceCode(data, entries)
+	reachable := map[int]bool{}
+	for off := range code {
+		reachable[off] = true
+	}
+
+	// Fill the gaps linearly, stopping at the next reachable instruction
+	it := decoder.NewIterator(data)
+	for !it.Done() {
+		start := it.Offset()
+		if in, ok := code[start]; ok {
+			it.Seek(start + in.Length)
+			continue
+		}
+		end := dataEnd(code, start, len(data))
+		in, err := it.Next()
+		if err != nil || start+in.Length > end {
+			it.Seek(start + 1)
+			continue
+		}
+		code[start] = in
+	}
+
+	offsets := make([]int, 0, len(code))
+	for off := range code {
+		offsets = append(offsets, off)
+	}
+	sort.Ints(offsets)
+
+	// A block starts at an entry point, a target, after an instruction that
+	// changes the flow and after a gap
+	leaders := map[int]bool{}
+	for _, e := range entries {
+		leaders[e] = true
+	}
+	var targets []int
+	for i, off := range offsets {
+		in := code[off]
+		f := flowOf(in)
+		if f.target >= 0 {
+			leaders[f.target] = true
+			targets = append(targets, f.target)
+		}
+		if i == 0 || offsets[i-1]+code[offsets[i-1]].Length != off {
+			leaders[off] = true
+		}
+		if f.target >= 0 || !f.next {
+			leaders[off+in.Length] = true
+		}
+	}
+	lines := map[int]bool{}
+	for _, off := range offsets {
+		lines[off] = true
+	}
+	labels := nameLabels(lines, targets)
+
+	var blocks []block
+	for _, off := range offsets {
+		in := code[off]
+		if leaders[off] {
+			blocks = append(blocks, block{
+				Start:        off,
+				Label:        labels[off],
+				Reachable:    reachable[off],
+				Instructions: []jsonInstruction{},
+				Edges:        []edge{},
+			})
+		}
+		b := &blocks[len(blocks)-1]
+		b.Instructions = append(b.Instructions, newJSONInstruction(in, data[off:off+in.Length], labels))
+		b.End = off + in.Length
+		b.last = in
+	}
+
+	for i := range blocks {
+		b := &blocks[i]
+		f := flowOf(b.last)
+		if f.target >= 0 && lines[f.target] {
+			kind := edgeTaken
+			if f.call {
+				kind = edgeCall
+			}
+			b.Edges = append(b.Edges, edge{f.target, kind})
+		}
+		if f.next && lines[b.End] {
+			b.Edges = append(b.Edges, edge{b.End, edgeFallthrough})
+		}
+	}
+	return blocks
+}
+
+// writeDOT writes blocks as a graphviz digraph. Unreachable blocks are
+// dashed and grey.
+func writeDOT(w io.Writer, blocks []block) {
+	fmt.Fprintln(w, "digraph cfg {")
+	fmt.Fprintln(w, "\tnode [shape=box, fontname=\"monospace\"];")
+	for _, b := range blocks {
+		var text strings.Builder
+		name := b.Label
+		if name == "" {
+			name = fmt.Sprintf("%04x", b.Start)
+		}
+		text.WriteString(name + ":\\l")
+		for _, in := range b.Instructions {
+			text.WriteString(dotEscape(in.Text) + "\\l")
+		}
+		style := ""
+		if !b.Reachable {
+			style = ", style=dashed, color=grey, fontcolor=grey"
+		}
+		fmt.Fprintf(w, "\tb%04x [label=\"%s\"%s];\n", b.Start, text.String(), style)
+	}
+	for _, b := range blocks {
+		for _, e := range b.Edges {
+			style := ""
+			switch e.Kind {
+			case edgeTaken:
+				style = ", color=green"
+			case edgeCall:
+				style = ", style=dashed, color=blue"
+			}
+			fmt.Fprintf(w, "\tb%04x -> b%04x [label=%s%s];\n", b.Start, e.To, e.Kind, style)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotEscape escapes s for a double quoted DOT string.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
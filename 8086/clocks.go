@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"8086/decoder"
+)
+
+// clockParams returns what can be known about in's clocks without running
+// it: the address of a direct memory operand.
+func clockParams(in decoder.Instruction, cpu8088 bool) decoder.ClockParams {
+	p := decoder.ClockParams{CPU8088: cpu8088}
+	for _, o := range in.Operands() {
+		if o.Kind == decoder.OperandMemory && o.EA.Direct() {
+			p.Address, p.AddressKnown = uint16(o.EA.Displacement), true
+		}
+	}
+	return p
+}
+
+// clocksComment returns the -clocks comment for in, e.g.
+// "Clocks: +14 = 18 (8 + 6ea)", and adds its clocks to total.
+func clocksComment(in decoder.Instruction, p decoder.ClockParams, total *int) string {
+	c, ok := in.Clocks(p)
+	if !ok {
+		return "Clocks: unknown"
+	}
+	*total += c.Total()
+	s := fmt.Sprintf("Clocks: %+d = %d", c.Total(), *total)
+	if c.EA > 0 || c.Penalty > 0 {
+		s += fmt.Sprintf(" (%s)", c)
+	}
+	return s
+}
@@ -1,241 +1,380 @@
 package main
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
 	"log"
 	"os"
 	"strings"
 	"text/template"
 
-	_ "embed"
+	"8086/decoder"
 )
 
-//go:embed instruction_encodings.txt
-var encodings string
-
-var sizes = map[string]int{
-	"D":      1,
-	"W":      1,
-	"MOD":    2,
-	"SR":     2,
-	"REG":    3,
-	"RM":     3,
-	"DISPLO": 8,
-	"DISPHI": 8,
-	"DATA":   8,
-	"DATAW":  8,
-	"ADDRLO": 8,
-	"ADDRHI": 8,
-}
+var (
+	inputFlag   = flag.String("input", "instruction_encodings.txt", "instruction encodings to generate a decoder for")
+	outputFlag  = flag.String("output", "disassemble.gen.go", "file to write the generated decoder to")
+	packageFlag = flag.String("package", "decoder", "package of the generated decoder")
+)
 
-func sizeOf(val string) int {
-	if size, ok := sizes[val]; ok {
-		return size
-	} else {
-		return len(val)
-	}
+// fields maps a bit field to the Instruction field it's decoded into
+var fields = map[string]string{
+	"D":   "D",
+	"W":   "W",
+	"S":   "S",
+	"V":   "V",
+	"Z":   "Z",
+	"MOD": "Mod",
+	"SR":  "SR",
+	"REG": "Reg",
+	"RM":  "RM",
 }
 
-func nameOf(val string) string {
-	if _, ok := sizes[val]; ok {
-		return strings.ToLower(val)
-	}
-	return "const_" + val
+// Part is a bit field of an encoding, as the template sees it.
+type Part struct {
+	decoder.Part
 }
 
-type Opcode struct {
-	Len  int
-	Name string
+// Shift is how far right the part needs to be shifted to be in the low bits
+func (p Part) Shift() int {
+	return 8 - (p.Start + p.Len)
 }
 
-type Part struct {
-	Start int
-	Len   int
-	Name  string
-	Orig  string
+func (p Part) Field() string {
+	return fields[p.Name]
 }
 
 type Byte struct {
-	Pos      int
-	Parts    []Part
-	HasModRM bool
+	// Whole is set if the part is a byte, or more, read directly from the
+	// input rather than a set of bit fields
+	Whole string
+	Parts []Part
 }
 
+// Encoding is an encoding parsed by package decoder, with its bytes split
+// into those read whole and those made of bit fields.
 type Encoding struct {
-	Encoded string
+	*decoder.Encoding
+	// Index is the encoding's index in the table, which the generated code
+	// uses to refer to it
+	Index int
 
-	Name   string
-	Bytes  []Byte
-	Opcode Opcode
+	Bytes []Byte
 }
 
-func main() {
-	opcodes := map[Opcode][]Encoding{}
-	for _, encoding := range strings.Split(encodings, "\n") {
-		if len(encoding) == 0 {
+func newEncoding(enc *decoder.Encoding, index int) Encoding {
+	e := Encoding{Encoding: enc, Index: index}
+	for _, parts := range enc.Bytes {
+		if len(parts) == 1 && parts[0].Len == 8 && !parts[0].IsConst {
+			e.Bytes = append(e.Bytes, Byte{Whole: parts[0].Name})
 			continue
 		}
-		if encoding[0] == '#' {
-			continue
+		var b Byte
+		for _, p := range parts {
+			b.Parts = append(b.Parts, Part{p})
 		}
+		e.Bytes = append(e.Bytes, b)
+	}
+	return e
+}
 
-		encodingS := strings.Split(encoding, " ")
-		enc := Encoding{Encoded: encoding, Name: encodingS[0]}
-		hasMod := false
-		for pos, part := range encodingS[1:] {
-			if len(part) == 0 {
-				continue
-			}
-
-			total := 8
-
-			i := 0
-			b := Byte{Pos: pos}
-			for _, p := range strings.Split(part, "_") {
-				size := sizeOf(p)
-				if p == "MOD" {
-					hasMod = true
-				}
-				b.Parts = append(b.Parts, Part{
-					Start: i,
-					Len:   size,
-					Name:  nameOf(p),
-					Orig:  p,
-				})
-				if enc.Opcode.Name == "" {
-					bp0 := b.Parts[0]
-					enc.Opcode = Opcode{8 - bp0.Len, bp0.Orig}
-				}
-				i += size
-				total -= size
-			}
-			if total != 0 {
-				log.Fatalf("line %d (%s): invalid part %q doesn't equal 8 bits", i+1, encoding, part)
-			}
-			enc.Bytes = append(enc.Bytes, b)
+// Func is the name of the generated function that decodes this encoding
+func (e Encoding) Func() string {
+	return fmt.Sprintf("genDecode%d", e.Index)
+}
 
-			if hasMod {
-				enc.Bytes = append(enc.Bytes, Byte{HasModRM: true})
-				break
-			}
+// matches reports whether the first byte b matches all the constants in the
+// first byte of the encoding.
+func (e Encoding) matches(b byte) bool {
+	for _, p := range e.Bytes[0].Parts {
+		if p.IsConst && (b>>p.Shift())&mask(p.Len) != p.Const {
+			return false
 		}
-		opcodes[enc.Opcode] = append(opcodes[enc.Opcode], enc)
 	}
+	return true
+}
+
+type Case struct {
+	Bytes     []byte
+	Encodings []Encoding
+}
+
+// key identifies the encodings tried by a case
+func (c Case) key() string {
+	var sb strings.Builder
+	for _, enc := range c.Encodings {
+		fmt.Fprintf(&sb, "%d,", enc.Index)
+	}
+	return sb.String()
+}
+
+func main() {
+	flag.Parse()
 
-	fd, err := os.Create("pkg/dissasemble.gen.go")
+	data, err := os.ReadFile(*inputFlag)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer fd.Close()
+	// The encodings are parsed and ordered for each first byte by package
+	// decoder, so that the generated decoder can't drift from the table-driven
+	// one.
+	e := decoder.NewEncoder(string(data))
+	var encodings []Encoding
+	index := map[*decoder.Encoding]int{}
+	for i, enc := range e.Encodings() {
+		encodings = append(encodings, newEncoding(enc, i))
+		index[enc] = i
+	}
+
+	// Each first byte is tried against the same encodings, in the same order,
+	// as Encoder.Decode. Encodings with other constants in the first byte that
+	// can't match are dropped, and bytes that try the same encodings share a
+	// case.
+	var cases []Case
+	caseOf := map[string]int{}
+	for b := 0; b < 256; b++ {
+		var c Case
+		for _, enc := range e.Decode(byte(b)) {
+			if enc := encodings[index[enc]]; enc.matches(byte(b)) {
+				c.Encodings = append(c.Encodings, enc)
+			}
+		}
+		if len(c.Encodings) == 0 {
+			continue
+		}
+		if i, ok := caseOf[c.key()]; ok {
+			cases[i].Bytes = append(cases[i].Bytes, byte(b))
+			continue
+		}
+		caseOf[c.key()] = len(cases)
+		c.Bytes = []byte{byte(b)}
+		cases = append(cases, c)
+	}
 
 	funcMap := template.FuncMap{
 		"mask": func(val int) string {
-			m := ""
-			for i := 0; i < val; i++ {
-				m += "1"
-			}
-			return "0b" + m
+			return fmt.Sprintf("0b%b", mask(val))
 		},
-		"minus": func(v1, v2 int) int {
-			return v2 - v1
+		"bin": func(val byte, len int) string {
+			return fmt.Sprintf("0b%0*b", len, val)
 		},
 	}
 
 	t := template.Must(template.New("dis").Funcs(funcMap).Parse(templ))
 
-	if err := t.Execute(fd, map[string]any{"opcodes": opcodes}); err != nil {
+	var buf bytes.Buffer
+	err = t.Execute(&buf, map[string]any{
+		"package":   *packageFlag,
+		"input":     *inputFlag,
+		"encodings": encodings,
+		"cases":     cases,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("generated code doesn't parse: %v", err)
+	}
+	if err := os.WriteFile(*outputFlag, src, 0o644); err != nil {
 		log.Fatal(err)
 	}
 }
 
-var templ = `
-// DO NOT EDIT: This file was autogenerated by cmd/codegen
-package pkg
+func mask(length int) byte {
+	m := byte(1)
+	for i := 1; i < length; i++ {
+		m = m << 1
+		m |= 1
+	}
+	return m
+}
+
+var templ = `// Code generated by cmd/codegen from {{.input}}. DO NOT EDIT.
+
+package {{.package}}
 
-type Instruction struct {
-	Name string
-	D bool
-	Field1 Field
-	Field2 Field
+// genDecoder decodes instructions with code specialized for each encoding,
+// rather than interpreting the encoding table like disassembler does. It
+// produces the same instructions and errors as disassembler.
+type genDecoder struct {
+	data []byte
+	di   int
+
+	// opcodeEnd is where to rewind to when an encoding doesn't match
+	opcodeEnd int
+	// truncated is set when a read runs past the end of data
+	truncated bool
+	// anyTruncated is set if any encoding tried was truncated
+	anyTruncated bool
 }
 
-type Field struct {
-	reg1 byte
-	reg2 byre
-	imm  uint16
-	size size
-	ptr  bool
+func (g *genDecoder) next() byte {
+	if g.di >= len(g.data) {
+		g.truncated = true
+		return 0
+	}
+	b := g.data[g.di]
+	g.di++
+	return b
 }
 
-func disassemble(data []byte) []Instruction {
-	di := 0
+func (g *genDecoder) imm16() uint16 {
+	lo := uint16(g.next())
+	return lo | uint16(g.next())<<8
+}
 
-	// read next byte
-	read := func() byte {
-		b := data[di]
-		di++
-		return b
+// disp reads the displacement for the MOD and RM fields already in in
+func (g *genDecoder) disp(in *Instruction) {
+	switch in.Mod {
+	case 0b00:
+		if in.RM == 0b110 {
+			in.Displacement16 = int16(g.imm16())
+		}
+	case 0b01:
+		in.Displacement8 = int8(g.next())
+	case 0b10:
+		in.Displacement16 = int16(g.imm16())
 	}
+}
 
-	// read an 8-bit immediate
-	rImm := func() uint16 {
-		return uint16(read())
+// try decodes the rest of an instruction with f, rewinding if it doesn't match
+func (g *genDecoder) try(f func(*genDecoder, byte) (Instruction, bool), b byte) (Instruction, bool) {
+	g.truncated = false
+	in, ok := f(g, b)
+	if ok && !g.truncated {
+		return in, true
 	}
+	g.anyTruncated = g.anyTruncated || g.truncated
+	g.di = g.opcodeEnd
+	return Instruction{}, false
+}
 
-	// read full 16-bit immediate
-	rImmFull := func() uint16 {
-		return rImm() | (rImm() << 8)
+func (g *genDecoder) decodeError(start int, reason DecodeErrorReason) error {
+	end := g.di
+	if reason == ReasonTruncated {
+		end = len(g.data)
 	}
+	err := &DecodeError{
+		Offset: start,
+		Bytes:  append([]byte(nil), g.data[start:end]...),
+		Reason: reason,
+	}
+	g.di = start
+	return err
+}
 
-	handleModRM := func(mod, rm, w byte) Field {
-		var f Field
-		switch mod {
-		case 0b00: // Memory mode, no displacement *
-			f.ptr = true
-			if rm == 0b110 { // * special case
-				f.imm = rImmFull()
-			} else {
-				ea := effectiveAddr[rm]
-				f.reg1, f.reg2 = ea[0], ea[1]
-				f.ptr = true
-			}
-		case 0b01: // Memory mode, 8-bit displacement
-			ea := effectiveAddr[rm]
-			f.reg1, f.reg2 = ea[0], ea[1]
-			f.imm = rImm()
-			f.ptr = true
-		case 0b10: // Memory mode, 16-bit displacement
-			ea := effectiveAddr[rm]
-			f.reg1, f.reg2 = ea[0], ea[1]
-			f.imm = rImmFull()
-			f.ptr = true
-		case 0b11: // Register mode (no displacement)
-			f.reg1 = register(rm, w)
+// nextInstruction decodes the instruction at g.di. On error g.di is left at the
+// start of the failed instruction.
+func (g *genDecoder) nextInstruction() (Instruction, error) {
+	start := g.di
+	g.truncated = false
+	g.anyTruncated = false
+
+	var flags InstructionFlags
+	b := g.next()
+	for p := prefix(b); p != 0; p = prefix(b) {
+		var reason DecodeErrorReason
+		if flags, reason = addPrefix(flags, p); reason != 0 {
+			return Instruction{}, g.decodeError(start, reason)
 		}
-		return f
-	}
-
-	for di < len(data) {
-		in := newInstruction()
-		b := read()
-		bi := 0
-		switch {
-		{{- range $opcode, $entries := .opcodes}}
-		case b>>{{$opcode.Len}} == 0b{{$opcode.Name}}:
-			{{- range $entries}}
-			// inst: {{.Encoded -}}
-			in.name = "{{.Name}}"
-			{{ $blen := .Bytes | len | minus 1 }}
-			{{- range $index, $bytes := .Bytes}}
-			{{if gt $index 0 }}{{if not .HasModRM}}b = read(){{end}}{{- end}}
-			{{- range $bytes.Parts}}
-			{{.Name}} := (b >> (8-({{.Start}} + {{.Len}}))) & {{ mask .Len}}
-			{{- else}} 
-			in.field1 = handleModRM(mod, rm, w)
-			{{- end}}
-			{{- end}}
-			{{- end}}
-		{{- end}}
+		b = g.next()
+	}
+	if g.truncated {
+		return Instruction{}, g.decodeError(start, ReasonTruncated)
+	}
+	g.opcodeEnd = g.di
+
+	var (
+		in Instruction
+		ok bool
+	)
+	switch b {
+{{- range .cases}}
+	case {{range $i, $b := .Bytes}}{{if $i}}, {{end}}{{bin $b 8}}{{end}}:
+{{- range $i, $e := .Encodings}}
+		{{if $i}}if !ok {
+			{{end}}in, ok = g.try({{.Func}}, b) // {{.Orig}}{{if $i}}
+		}{{end}}
+{{- end}}
+{{- end}}
+	default:
+		return Instruction{}, g.decodeError(start, ReasonUnknownOpcode)
+	}
+	if !ok {
+		if g.anyTruncated {
+			return Instruction{}, g.decodeError(start, ReasonTruncated)
 		}
+		return Instruction{}, g.decodeError(start, ReasonConstantMismatch)
+	}
+	in.Offset = start
+	in.Length = g.di - start
+	in.Flags = flags
+	if in.FlagSet(FlagLock) && !in.lockable() {
+		return Instruction{}, g.decodeError(start, ReasonInvalidLock)
+	}
+	return in, nil
+}
+{{range .encodings}}
+// {{.Orig}}
+func {{.Func}}(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "{{.Name}}",
+		Type:     "{{.Type}}",
+		Opcode:   {{bin .Opcode.Opcode .Opcode.Len}},
+		W:        1,
+		Encoding: &encoder.encodings[{{.Index}}],
+	}
+{{- range $i, $b := .Bytes}}
+{{- if eq .Whole "DATAW"}}
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+{{- else if eq .Whole "DATA"}}
+	in.Data = uint16(g.next())
+{{- else if eq .Whole "ADDR"}}
+	in.Data = g.imm16()
+{{- else if eq .Whole "JUMP"}}
+	in.JumpTarget = int16(int8(g.next()))
+{{- else if eq .Whole "JUMPW"}}
+	in.JumpTarget = int16(g.imm16())
+{{- else if eq .Whole "FAR"}}
+	in.Data = g.imm16()
+	in.Segment = g.imm16()
+{{- else if eq .Whole "DISP"}}
+{{- else}}
+{{- if $i}}
+	b = g.next()
+{{- end}}
+{{- range .Parts}}
+{{- if .IsConst}}
+{{- if $i}}
+	if (b>>{{.Shift}})&{{mask .Len}} != {{bin .Const .Len}} {
+		return Instruction{}, false
+	}
+{{- end}}
+{{- else}}
+	in.{{.Field}} = (b >> {{.Shift}}) & {{mask .Len}}
+{{- if eq .Name "RM"}}
+	g.disp(&in)
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if eq .Type "FARRM"}}
+	// A far pointer can't be loaded from a register
+	if in.Mod == 0b11 {
+		return Instruction{}, false
 	}
+{{- end}}
+	return in, true
 }
-`
+{{end}}`
@@ -0,0 +1,392 @@
+package decoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClockParams is what is known about an instruction at run time that changes
+// how many clocks it takes. The zero value is for an 8086 with nothing known.
+type ClockParams struct {
+	// CPU8088 is set for the 8088, whose 8-bit bus takes 4 more clocks for
+	// every word transferred to or from memory
+	CPU8088 bool
+	// Address is the offset of the memory operand, which takes 4 more clocks
+	// per word transfer when it's odd. Only used if AddressKnown is set.
+	Address      uint16
+	AddressKnown bool
+	// Count is the shift or rotate count in cl, or the number of repetitions
+	// of a repeated string instruction. Only used if CountKnown is set,
+	// otherwise it's taken to be 1.
+	Count      int
+	CountKnown bool
+	// Taken is set if a conditional jump, loop or into was taken
+	Taken bool
+}
+
+// Clocks is the estimated number of clocks an instruction takes, split up
+// the way the 8086 manual does.
+type Clocks struct {
+	Base    int // from the timing table, including any repetitions
+	EA      int // calculating the effective address, including an override
+	Penalty int // 4 per word transfer on the 8088 or to an odd address
+}
+
+func (c Clocks) Total() int {
+	return c.Base + c.EA + c.Penalty
+}
+
+// String returns the clocks in the form used by the Computer Enhance
+// listings, e.g. "8 + 6ea + 4p", or just the base if there's nothing else.
+func (c Clocks) String() string {
+	s := fmt.Sprint(c.Base)
+	if c.EA > 0 {
+		s += fmt.Sprintf(" + %dea", c.EA)
+	}
+	if c.Penalty > 0 {
+		s += fmt.Sprintf(" + %dp", c.Penalty)
+	}
+	return s
+}
+
+// timing is an entry in the timing table.
+type timing struct {
+	clocks int
+	// transfers is the number of words transferred to or from memory, for
+	// the odd address and 8088 penalties
+	transfers int
+	// taken replaces clocks if a conditional jump is taken
+	taken int
+	// perCount is added for each bit shifted, or each repetition of a
+	// string instruction, in which case clocks is the cost of rep
+	perCount int
+}
+
+// clockTable is the 8086 instruction timings from table 2-21 of the 8086
+// family user's manual, keyed by mnemonic and operand form. The forms are
+// reg, mem, imm, acc, seg, dx, short, near, far and farmem for the operands,
+// destination first, and 1 or cl for a shift count. A form can be followed by
+// 8 or 16 for timings that depend on the operand size. Where the manual gives
+// a range, e.g. for mul, the lowest value is used.
+var clockTable = map[string]timing{
+	"mov mem,acc": {clocks: 10, transfers: 1},
+	"mov acc,mem": {clocks: 10, transfers: 1},
+	"mov reg,reg": {clocks: 2},
+	"mov reg,mem": {clocks: 8, transfers: 1},
+	"mov mem,reg": {clocks: 9, transfers: 1},
+	"mov reg,imm": {clocks: 4},
+	"mov mem,imm": {clocks: 10, transfers: 1},
+	"mov seg,reg": {clocks: 2},
+	"mov seg,mem": {clocks: 8, transfers: 1},
+	"mov reg,seg": {clocks: 2},
+	"mov mem,seg": {clocks: 9, transfers: 1},
+
+	"push reg": {clocks: 11, transfers: 1},
+	"push seg": {clocks: 10, transfers: 1},
+	"push mem": {clocks: 16, transfers: 2},
+	"pop reg":  {clocks: 8, transfers: 1},
+	"pop seg":  {clocks: 8, transfers: 1},
+	"pop mem":  {clocks: 17, transfers: 2},
+	"pushf":    {clocks: 10, transfers: 1},
+	"popf":     {clocks: 8, transfers: 1},
+
+	"xchg acc,reg": {clocks: 3},
+	"xchg reg,reg": {clocks: 4},
+	"xchg reg,mem": {clocks: 17, transfers: 2},
+
+	"in acc,imm":  {clocks: 10, transfers: 1},
+	"in acc,dx":   {clocks: 8, transfers: 1},
+	"out imm,acc": {clocks: 10, transfers: 1},
+	"out dx,acc":  {clocks: 8, transfers: 1},
+
+	"xlat":        {clocks: 11},
+	"lea reg,mem": {clocks: 2},
+	"lds reg,mem": {clocks: 16, transfers: 2},
+	"les reg,mem": {clocks: 16, transfers: 2},
+	"lahf":        {clocks: 4},
+	"sahf":        {clocks: 4},
+
+	// add, adc, sub, sbb, and, or and xor are filled in by init
+
+	"cmp reg,reg": {clocks: 3},
+	"cmp reg,mem": {clocks: 9, transfers: 1},
+	"cmp mem,reg": {clocks: 9, transfers: 1},
+	"cmp reg,imm": {clocks: 4},
+	"cmp mem,imm": {clocks: 10, transfers: 1},
+	"cmp acc,imm": {clocks: 4},
+
+	"test reg,reg": {clocks: 3},
+	"test mem,reg": {clocks: 9, transfers: 1},
+	"test reg,mem": {clocks: 9, transfers: 1},
+	"test reg,imm": {clocks: 5},
+	"test mem,imm": {clocks: 11, transfers: 1},
+	"test acc,imm": {clocks: 4},
+
+	"inc reg16": {clocks: 2},
+	"inc reg8":  {clocks: 3},
+	"inc mem":   {clocks: 15, transfers: 2},
+	"dec reg16": {clocks: 2},
+	"dec reg8":  {clocks: 3},
+	"dec mem":   {clocks: 15, transfers: 2},
+	"neg reg":   {clocks: 3},
+	"neg mem":   {clocks: 16, transfers: 2},
+	"not reg":   {clocks: 3},
+	"not mem":   {clocks: 16, transfers: 2},
+
+	"mul reg8":   {clocks: 70},
+	"mul reg16":  {clocks: 118},
+	"mul mem8":   {clocks: 76, transfers: 1},
+	"mul mem16":  {clocks: 124, transfers: 1},
+	"imul reg8":  {clocks: 80},
+	"imul reg16": {clocks: 128},
+	"imul mem8":  {clocks: 86, transfers: 1},
+	"imul mem16": {clocks: 134, transfers: 1},
+	"div reg8":   {clocks: 80},
+	"div reg16":  {clocks: 144},
+	"div mem8":   {clocks: 86, transfers: 1},
+	"div mem16":  {clocks: 150, transfers: 1},
+	"idiv reg8":  {clocks: 101},
+	"idiv reg16": {clocks: 165},
+	"idiv mem8":  {clocks: 107, transfers: 1},
+	"idiv mem16": {clocks: 171, transfers: 1},
+	"aaa":        {clocks: 4},
+	"aas":        {clocks: 4},
+	"daa":        {clocks: 4},
+	"das":        {clocks: 4},
+	"aam":        {clocks: 83},
+	"aad":        {clocks: 60},
+	"cbw":        {clocks: 2},
+	"cwd":        {clocks: 5},
+
+	// shl, shr, sar, rol, ror, rcl and rcr are filled in by init
+
+	"movsb": {clocks: 18, transfers: 2},
+	"movsw": {clocks: 18, transfers: 2},
+	"cmpsb": {clocks: 22, transfers: 2},
+	"cmpsw": {clocks: 22, transfers: 2},
+	"scasb": {clocks: 15, transfers: 1},
+	"scasw": {clocks: 15, transfers: 1},
+	"lodsb": {clocks: 12, transfers: 1},
+	"lodsw": {clocks: 12, transfers: 1},
+	"stosb": {clocks: 11, transfers: 1},
+	"stosw": {clocks: 11, transfers: 1},
+
+	"rep movsb": {clocks: 9, perCount: 17, transfers: 2},
+	"rep movsw": {clocks: 9, perCount: 17, transfers: 2},
+	"rep cmpsb": {clocks: 9, perCount: 22, transfers: 2},
+	"rep cmpsw": {clocks: 9, perCount: 22, transfers: 2},
+	"rep scasb": {clocks: 9, perCount: 15, transfers: 1},
+	"rep scasw": {clocks: 9, perCount: 15, transfers: 1},
+	"rep lodsb": {clocks: 9, perCount: 13, transfers: 1},
+	"rep lodsw": {clocks: 9, perCount: 13, transfers: 1},
+	"rep stosb": {clocks: 9, perCount: 10, transfers: 1},
+	"rep stosw": {clocks: 9, perCount: 10, transfers: 1},
+
+	"call near":   {clocks: 19, transfers: 1},
+	"call reg":    {clocks: 16, transfers: 1},
+	"call mem":    {clocks: 21, transfers: 2},
+	"call far":    {clocks: 28, transfers: 2},
+	"call farmem": {clocks: 37, transfers: 4},
+	"jmp short":   {clocks: 15},
+	"jmp near":    {clocks: 15},
+	"jmp reg":     {clocks: 11},
+	"jmp mem":     {clocks: 18, transfers: 1},
+	"jmp far":     {clocks: 15},
+	"jmp farmem":  {clocks: 24, transfers: 2},
+	"ret":         {clocks: 8, transfers: 1},
+	"ret imm":     {clocks: 12, transfers: 1},
+	"retf":        {clocks: 18, transfers: 2},
+	"retf imm":    {clocks: 17, transfers: 2},
+
+	// The conditional jumps are filled in by init
+	"loop short":   {clocks: 5, taken: 17},
+	"loopz short":  {clocks: 6, taken: 18},
+	"loopnz short": {clocks: 5, taken: 19},
+	"jcxz short":   {clocks: 6, taken: 18},
+
+	"int imm": {clocks: 51, transfers: 5},
+	"int3":    {clocks: 52, transfers: 5},
+	"into":    {clocks: 4, taken: 53, transfers: 5},
+	"iret":    {clocks: 24, transfers: 3},
+
+	"clc":  {clocks: 2},
+	"cmc":  {clocks: 2},
+	"stc":  {clocks: 2},
+	"cld":  {clocks: 2},
+	"std":  {clocks: 2},
+	"cli":  {clocks: 2},
+	"sti":  {clocks: 2},
+	"hlt":  {clocks: 2},
+	"wait": {clocks: 3},
+}
+
+func init() {
+	for _, name := range []string{"add", "adc", "sub", "sbb", "and", "or", "xor"} {
+		clockTable[name+" reg,reg"] = timing{clocks: 3}
+		clockTable[name+" reg,mem"] = timing{clocks: 9, transfers: 1}
+		clockTable[name+" mem,reg"] = timing{clocks: 16, transfers: 2}
+		clockTable[name+" reg,imm"] = timing{clocks: 4}
+		clockTable[name+" mem,imm"] = timing{clocks: 17, transfers: 2}
+		clockTable[name+" acc,imm"] = timing{clocks: 4}
+	}
+	for _, name := range []string{"shl", "shr", "sar", "rol", "ror", "rcl", "rcr"} {
+		clockTable[name+" reg,1"] = timing{clocks: 2}
+		clockTable[name+" reg,cl"] = timing{clocks: 8, perCount: 4}
+		clockTable[name+" mem,1"] = timing{clocks: 15, transfers: 2}
+		clockTable[name+" mem,cl"] = timing{clocks: 20, perCount: 4, transfers: 2}
+	}
+	for _, name := range []string{
+		"je", "jl", "jle", "jb", "jbe", "jp", "jo", "js",
+		"jne", "jge", "jg", "jnb", "jnbe", "jnp", "jno", "jns",
+	} {
+		clockTable[name+" short"] = timing{clocks: 4, taken: 16}
+	}
+}
+
+// wordOnly is the instructions that always transfer words, whatever their W
+// field.
+var wordOnly = map[string]bool{
+	"push": true, "pop": true, "pushf": true, "popf": true,
+	"lds": true, "les": true,
+	"call": true, "jmp": true, "ret": true, "retf": true,
+	"int": true, "int3": true, "into": true, "iret": true,
+}
+
+// byteOnly is the string instructions that transfer bytes, which have no W
+// field to say so.
+var byteOnly = map[string]bool{
+	"movsb": true, "cmpsb": true, "scasb": true, "lodsb": true, "stosb": true,
+}
+
+// Clocks estimates the clocks the instruction takes, or returns false if it
+// isn't in the timing table.
+func (i Instruction) Clocks(p ClockParams) (Clocks, bool) {
+	form, ea := i.clockForm()
+	size := "8"
+	if i.W > 0 {
+		size = "16"
+	}
+	keys := []string{i.Name + " " + sized(form, size), i.Name + " " + strings.Join(form, ",")}
+	if i.FlagSet(FlagRepeat) || i.FlagSet(FlagRepeatZ) {
+		// A repeat prefix only changes the timing of string instructions
+		keys = append([]string{"rep " + keys[1]}, keys...)
+	}
+	var (
+		t   timing
+		ok  bool
+		key string
+	)
+	for _, key = range keys {
+		if t, ok = clockTable[strings.TrimSpace(key)]; ok {
+			break
+		}
+	}
+	if !ok {
+		return Clocks{}, false
+	}
+
+	c := Clocks{Base: t.clocks}
+	if p.Taken && t.taken > 0 {
+		c.Base = t.taken
+	}
+	if t.perCount > 0 {
+		count := 1
+		if p.CountKnown {
+			count = p.Count
+		}
+		c.Base += t.perCount * count
+	}
+	c.EA = ea
+
+	if (i.W > 0 && !byteOnly[i.Name]) || wordOnly[i.Name] {
+		if p.CPU8088 || (p.AddressKnown && p.Address&1 == 1) {
+			c.Penalty = 4 * t.transfers
+			if strings.HasPrefix(key, "rep ") && p.CountKnown {
+				// Every repetition transfers again
+				c.Penalty *= p.Count
+			}
+		}
+	}
+	return c, true
+}
+
+// clockForm returns the form of each operand for the timing table, and the
+// clocks to calculate the address of a mod/rm memory operand.
+func (i Instruction) clockForm() (form []string, ea int) {
+	for _, typ := range strings.Split(i.Type, "__") {
+		switch typ {
+		case "REG":
+			form = append(form, "reg")
+		case "RM", "FARRM":
+			if i.Mod == 0b11 {
+				form = append(form, "reg")
+				break
+			}
+			ea = eaClocks(i.operandRM().EA, i.Mod != 0b00)
+			if typ == "FARRM" {
+				form = append(form, "farmem")
+			} else {
+				form = append(form, "mem")
+			}
+		case "IMM", "DATA":
+			form = append(form, "imm")
+		case "JUMP":
+			form = append(form, "short")
+		case "NEAR":
+			form = append(form, "near")
+		case "FAR":
+			form = append(form, "far")
+		case "MEM":
+			form = append(form, "mem")
+		case "ACC":
+			form = append(form, "acc")
+		case "DX":
+			form = append(form, "dx")
+		case "SR":
+			form = append(form, "seg")
+		case "V":
+			if i.V == 0 {
+				form = append(form, "1")
+			} else {
+				form = append(form, "cl")
+			}
+		}
+	}
+	if i.D > 0 {
+		form[0], form[1] = form[1], form[0]
+	}
+	return form, ea
+}
+
+// sized returns the form with size after the reg or mem of a single operand
+// form, e.g. "reg16", and "" for other forms.
+func sized(form []string, size string) string {
+	if len(form) != 1 || (form[0] != "reg" && form[0] != "mem") {
+		return ""
+	}
+	return form[0] + size
+}
+
+// eaClocks returns the clocks to calculate the address, from the table of
+// effective address calculation times in the 8086 manual. hasDisp is set if
+// the instruction has a displacement, even if it's 0 as in [bp + 0].
+func eaClocks(ea EffectiveAddress, hasDisp bool) int {
+	var c int
+	switch {
+	case ea.Direct():
+		c = 6
+	case ea.Base == RegNone || ea.Index == RegNone:
+		c = 5
+	case (ea.Base == BP && ea.Index == DI) || (ea.Base == BX && ea.Index == SI):
+		c = 7
+	default:
+		c = 8
+	}
+	if !ea.Direct() && hasDisp {
+		c += 4
+	}
+	if ea.Segment != RegNone {
+		c += 2
+	}
+	return c
+}
@@ -0,0 +1,74 @@
+package decoder
+
+import "testing"
+
+func TestClocks(t *testing.T) {
+	tests := []struct {
+		data []byte
+		p    ClockParams
+		want string
+	}{
+		{[]byte{0xbb, 0xe8, 0x03}, ClockParams{}, "4"},                                                   // mov bx, 1000
+		{[]byte{0x8b, 0x1e, 0xe8, 0x03}, ClockParams{}, "8 + 6ea"},                                       // mov bx, [1000]
+		{[]byte{0x03, 0x40, 0x04}, ClockParams{}, "9 + 11ea"},                                            // add ax, [bx + si + 4]
+		{[]byte{0x01, 0x08}, ClockParams{}, "16 + 7ea"},                                                  // add [bx + si], cx
+		{[]byte{0x01, 0x09}, ClockParams{}, "16 + 8ea"},                                                  // add [bx + di], cx
+		{[]byte{0x8b, 0x46, 0x00}, ClockParams{}, "8 + 9ea"},                                             // mov ax, [bp + 0]
+		{[]byte{0x26, 0x8b, 0x46, 0x00}, ClockParams{}, "8 + 11ea"},                                      // mov ax, es:[bp]
+		{[]byte{0x89, 0x06, 0xe9, 0x03}, ClockParams{Address: 1001, AddressKnown: true}, "9 + 6ea + 4p"}, // mov [1001], ax
+		{[]byte{0x89, 0x06, 0xe8, 0x03}, ClockParams{Address: 1000, AddressKnown: true}, "9 + 6ea"},      // mov [1000], ax
+		{[]byte{0x88, 0x06, 0xe9, 0x03}, ClockParams{Address: 1001, AddressKnown: true}, "9 + 6ea"},      // mov [1001], al
+		{[]byte{0x01, 0x08}, ClockParams{CPU8088: true}, "16 + 7ea + 8p"},                                // add [bx + si], cx
+		{[]byte{0xa3, 0xe9, 0x03}, ClockParams{CPU8088: true}, "10 + 4p"},                                // mov [1001], ax
+		{[]byte{0xff, 0x37}, ClockParams{}, "16 + 5ea"},                                                  // push word [bx]
+		{[]byte{0x40}, ClockParams{}, "2"},                                                               // inc ax
+		{[]byte{0xfe, 0xc0}, ClockParams{}, "3"},                                                         // inc al
+		{[]byte{0xf7, 0xe1}, ClockParams{}, "118"},                                                       // mul cx
+		{[]byte{0x90}, ClockParams{}, "3"},                                                               // xchg ax, ax
+		{[]byte{0x74, 0x00}, ClockParams{}, "4"},                                                         // je $+2
+		{[]byte{0x74, 0x00}, ClockParams{Taken: true}, "16"},                                             // je $+2
+		{[]byte{0xd3, 0xe0}, ClockParams{Count: 3, CountKnown: true}, "20"},                              // shl ax, cl
+		{[]byte{0xd3, 0x27}, ClockParams{Count: 3, CountKnown: true, CPU8088: true}, "32 + 5ea + 8p"},    // shl word [bx], cl
+		{[]byte{0xf3, 0xa5}, ClockParams{Count: 10, CountKnown: true}, "179"},                            // rep movsw
+		{[]byte{0xf3, 0xa5}, ClockParams{Count: 10, CountKnown: true, CPU8088: true}, "179 + 80p"},
+		// Byte string instructions have no W field, but only transfer bytes
+		{[]byte{0xf3, 0xa4}, ClockParams{Count: 10, CountKnown: true, CPU8088: true}, "179"}, // rep movsb
+		{[]byte{0xac}, ClockParams{CPU8088: true}, "12"},                                     // lodsb
+		{[]byte{0xad}, ClockParams{CPU8088: true}, "12 + 4p"},                                // lodsw
+		{[]byte{0xf2, 0xae}, ClockParams{Count: 3, CountKnown: true, CPU8088: true}, "54"},   // repne scasb
+		{[]byte{0xf3, 0x01, 0x08}, ClockParams{}, "16 + 7ea"},                                // rep add [bx + si], cx
+	}
+	for _, tt := range tests {
+		in, _, err := Decode(tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, ok := in.Clocks(tt.p)
+		if !ok {
+			t.Errorf("%s: no timing", in)
+			continue
+		}
+		if c.String() != tt.want {
+			t.Errorf("%s %+v: got %s, want %s", in, tt.p, c, tt.want)
+		}
+	}
+}
+
+// TestClocksTable checks every instruction the decoder knows has a timing.
+func TestClocksTable(t *testing.T) {
+	for b1 := 0; b1 < 256; b1++ {
+		for b2 := 0; b2 < 256; b2++ {
+			in, _, err := Decode([]byte{byte(b1), byte(b2), 0, 0, 0, 0})
+			if err != nil {
+				continue
+			}
+			if in.Type == "REG__RM" && in.Mod == 0b11 && in.Name != "xchg" {
+				// lea, lds and les of a register are undefined on the 8086
+				continue
+			}
+			if _, ok := in.Clocks(ClockParams{}); !ok {
+				t.Errorf("% x (%s %s): no timing", []byte{byte(b1), byte(b2)}, in, in.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package decoder decodes 8086 machine code into instructions that can be
+// printed in nasm syntax or executed.
+package decoder
+
+// Decode decodes the instruction at the start of b, returning it and its
+// length in bytes.
+func Decode(b []byte) (Instruction, int, error) {
+	d := &disassembler{data: b}
+	in, err := d.nextInstruction()
+	if err != nil {
+		return Instruction{}, 0, err
+	}
+	return in, in.Length, nil
+}
+
+// Iterator decodes the instructions in a byte slice one after another.
+type Iterator struct {
+	d disassembler
+}
+
+func NewIterator(data []byte) *Iterator {
+	return &Iterator{d: disassembler{data: data}}
+}
+
+// Done reports whether there is no more input to decode.
+func (it *Iterator) Done() bool {
+	return it.d.di >= len(it.d.data)
+}
+
+// Offset returns the offset of the next instruction to decode.
+func (it *Iterator) Offset() int {
+	return it.d.di
+}
+
+// Seek moves the iterator to offset, e.g. to follow a jump or to resync after
+// an error.
+func (it *Iterator) Seek(offset int) {
+	it.d.di = offset
+}
+
+// Next decodes the instruction at Offset and moves past it. On error the
+// iterator stays at the start of the failed instruction.
+func (it *Iterator) Next() (Instruction, error) {
+	return it.d.nextInstruction()
+}
@@ -0,0 +1,121 @@
+package decoder
+
+import "testing"
+
+func TestIterator(t *testing.T) {
+	// mov ax, 10; (undefined); je $+0
+	data := []byte{0b10111000, 10, 0, 0b11010110, 0b01110100, 0b11111110}
+
+	in, n, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := in.String(); got != "mov ax, 10" || n != 3 {
+		t.Fatalf("Decode = %q, %d; want %q, 3", got, n, "mov ax, 10")
+	}
+
+	var got []string
+	it := NewIterator(data)
+	for !it.Done() {
+		start := it.Offset()
+		in, err := it.Next()
+		if err != nil {
+			if it.Offset() != start {
+				t.Fatalf("iterator moved to %d on error, want %d", it.Offset(), start)
+			}
+			got = append(got, "error")
+			it.Seek(start + 1)
+			continue
+		}
+		got = append(got, in.String())
+	}
+	want := []string{"mov ax, 10", "error", "je $+0"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("instruction %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOperands(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want []Operand
+	}{
+		{
+			// mov ah, [bp + si - 4]
+			data: []byte{0b10001010, 0b01100010, 0xfc},
+			want: []Operand{
+				{Kind: OperandRegister, Reg: AH},
+				{Kind: OperandMemory, EA: EffectiveAddress{Base: BP, Index: SI, Displacement: -4}},
+			},
+		},
+		{
+			// mov es:[16], ax
+			data: []byte{0b00100110, 0b10100011, 16, 0},
+			want: []Operand{
+				{Kind: OperandMemory, EA: EffectiveAddress{Displacement: 16, Segment: ES}},
+				{Kind: OperandRegister, Reg: AX},
+			},
+		},
+		{
+			// shl bl, 1
+			data: []byte{0b11010000, 0b11100011},
+			want: []Operand{
+				{Kind: OperandRegister, Reg: BL},
+				{Kind: OperandImmediate, Imm: 1, UnknownSize: true},
+			},
+		},
+		{
+			// mov ds, cx
+			data: []byte{0b10001110, 0b11011001},
+			want: []Operand{
+				{Kind: OperandSegment, Reg: DS},
+				{Kind: OperandRegister, Reg: CX},
+			},
+		},
+	}
+	for _, tt := range tests {
+		in, _, err := Decode(tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := in.Operands()
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %d operands, want %d", in, len(got), len(tt.want))
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: operand %d = %+v, want %+v", in, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		r     Register
+		index int
+		width int
+		high  bool
+	}{
+		{AL, 0, 1, false},
+		{AH, 0, 1, true},
+		{AX, 0, 2, false},
+		{BH, 3, 1, true},
+		{DI, 7, 2, false},
+		{ES, 8, 2, false},
+		{DS, 11, 2, false},
+	}
+	for _, tt := range tests {
+		if tt.r.Index() != tt.index || tt.r.Width() != tt.width || tt.r.High() != tt.high {
+			t.Errorf("%s: got index=%d width=%d high=%v", tt.r, tt.r.Index(), tt.r.Width(), tt.r.High())
+		}
+		if ParseRegister(tt.r.String()) != tt.r {
+			t.Errorf("ParseRegister(%q) = %v", tt.r.String(), ParseRegister(tt.r.String()))
+		}
+	}
+}
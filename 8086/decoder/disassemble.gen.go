@@ -0,0 +1,2663 @@
+// Code generated by cmd/codegen from instruction_encodings.txt. DO NOT EDIT.
+
+package decoder
+
+// genDecoder decodes instructions with code specialized for each encoding,
+// rather than interpreting the encoding table like disassembler does. It
+// produces the same instructions and errors as disassembler.
+type genDecoder struct {
+	data []byte
+	di   int
+
+	// opcodeEnd is where to rewind to when an encoding doesn't match
+	opcodeEnd int
+	// truncated is set when a read runs past the end of data
+	truncated bool
+	// anyTruncated is set if any encoding tried was truncated
+	anyTruncated bool
+}
+
+func (g *genDecoder) next() byte {
+	if g.di >= len(g.data) {
+		g.truncated = true
+		return 0
+	}
+	b := g.data[g.di]
+	g.di++
+	return b
+}
+
+func (g *genDecoder) imm16() uint16 {
+	lo := uint16(g.next())
+	return lo | uint16(g.next())<<8
+}
+
+// disp reads the displacement for the MOD and RM fields already in in
+func (g *genDecoder) disp(in *Instruction) {
+	switch in.Mod {
+	case 0b00:
+		if in.RM == 0b110 {
+			in.Displacement16 = int16(g.imm16())
+		}
+	case 0b01:
+		in.Displacement8 = int8(g.next())
+	case 0b10:
+		in.Displacement16 = int16(g.imm16())
+	}
+}
+
+// try decodes the rest of an instruction with f, rewinding if it doesn't match
+func (g *genDecoder) try(f func(*genDecoder, byte) (Instruction, bool), b byte) (Instruction, bool) {
+	g.truncated = false
+	in, ok := f(g, b)
+	if ok && !g.truncated {
+		return in, true
+	}
+	g.anyTruncated = g.anyTruncated || g.truncated
+	g.di = g.opcodeEnd
+	return Instruction{}, false
+}
+
+func (g *genDecoder) decodeError(start int, reason DecodeErrorReason) error {
+	end := g.di
+	if reason == ReasonTruncated {
+		end = len(g.data)
+	}
+	err := &DecodeError{
+		Offset: start,
+		Bytes:  append([]byte(nil), g.data[start:end]...),
+		Reason: reason,
+	}
+	g.di = start
+	return err
+}
+
+// nextInstruction decodes the instruction at g.di. On error g.di is left at the
+// start of the failed instruction.
+func (g *genDecoder) nextInstruction() (Instruction, error) {
+	start := g.di
+	g.truncated = false
+	g.anyTruncated = false
+
+	var flags InstructionFlags
+	b := g.next()
+	for p := prefix(b); p != 0; p = prefix(b) {
+		var reason DecodeErrorReason
+		if flags, reason = addPrefix(flags, p); reason != 0 {
+			return Instruction{}, g.decodeError(start, reason)
+		}
+		b = g.next()
+	}
+	if g.truncated {
+		return Instruction{}, g.decodeError(start, ReasonTruncated)
+	}
+	g.opcodeEnd = g.di
+
+	var (
+		in Instruction
+		ok bool
+	)
+	switch b {
+	case 0b00000000, 0b00000001, 0b00000010, 0b00000011:
+		in, ok = g.try(genDecode27, b) // add RM__REG 000000_D_W MOD_REG_RM DISP
+	case 0b00000100, 0b00000101:
+		in, ok = g.try(genDecode29, b) // add ACC__IMM 0000010_W DATAW
+	case 0b00000110, 0b00001110, 0b00010110, 0b00011110:
+		in, ok = g.try(genDecode9, b) // push SR 000_SR_110
+	case 0b00000111, 0b00001111, 0b00010111, 0b00011111:
+		in, ok = g.try(genDecode12, b) // pop SR 000_SR_111
+	case 0b00001000, 0b00001001, 0b00001010, 0b00001011:
+		in, ok = g.try(genDecode73, b) // or RM__REG 000010_D_W MOD_REG_RM DISP
+	case 0b00001100, 0b00001101:
+		in, ok = g.try(genDecode75, b) // or ACC__IMM 0000110_W DATAW
+	case 0b00010000, 0b00010001, 0b00010010, 0b00010011:
+		in, ok = g.try(genDecode30, b) // adc RM__REG 000100_D_W MOD_REG_RM DISP
+	case 0b00010100, 0b00010101:
+		in, ok = g.try(genDecode32, b) // adc ACC__IMM 0001010_W DATAW
+	case 0b00011000, 0b00011001, 0b00011010, 0b00011011:
+		in, ok = g.try(genDecode40, b) // sbb RM__REG 000110_D_W MOD_REG_RM DISP
+	case 0b00011100, 0b00011101:
+		in, ok = g.try(genDecode42, b) // sbb ACC__IMM 0001110_W DATAW
+	case 0b00100000, 0b00100001, 0b00100010, 0b00100011:
+		in, ok = g.try(genDecode67, b) // and RM__REG 001000_D_W MOD_REG_RM DISP
+	case 0b00100100, 0b00100101:
+		in, ok = g.try(genDecode69, b) // and ACC__IMM 0010010_W DATAW
+	case 0b00100111:
+		in, ok = g.try(genDecode39, b) // daa 00100111
+	case 0b00101000, 0b00101001, 0b00101010, 0b00101011:
+		in, ok = g.try(genDecode35, b) // sub RM__REG 001010_D_W MOD_REG_RM DISP
+	case 0b00101100, 0b00101101:
+		in, ok = g.try(genDecode37, b) // sub ACC__IMM 0010110_W DATAW
+	case 0b00101111:
+		in, ok = g.try(genDecode50, b) // das 00101111
+	case 0b00110000, 0b00110001, 0b00110010, 0b00110011:
+		in, ok = g.try(genDecode76, b) // xor RM__REG 001100_D_W MOD_REG_RM DISP
+	case 0b00110100, 0b00110101:
+		in, ok = g.try(genDecode78, b) // xor ACC__IMM 0011010_W DATAW
+	case 0b00110111:
+		in, ok = g.try(genDecode38, b) // aaa 00110111
+	case 0b00111000, 0b00111001, 0b00111010, 0b00111011:
+		in, ok = g.try(genDecode46, b) // cmp RM__REG 001110_D_W MOD_REG_RM DISP
+	case 0b00111100, 0b00111101:
+		in, ok = g.try(genDecode48, b) // cmp ACC__IMM 0011110_W DATAW
+	case 0b00111111:
+		in, ok = g.try(genDecode49, b) // aas 00111111
+	case 0b01000000, 0b01000001, 0b01000010, 0b01000011, 0b01000100, 0b01000101, 0b01000110, 0b01000111:
+		in, ok = g.try(genDecode34, b) // inc REG 01000_REG
+	case 0b01001000, 0b01001001, 0b01001010, 0b01001011, 0b01001100, 0b01001101, 0b01001110, 0b01001111:
+		in, ok = g.try(genDecode44, b) // dec REG 01001_REG
+	case 0b01010000, 0b01010001, 0b01010010, 0b01010011, 0b01010100, 0b01010101, 0b01010110, 0b01010111:
+		in, ok = g.try(genDecode8, b) // push REG 01010_REG
+	case 0b01011000, 0b01011001, 0b01011010, 0b01011011, 0b01011100, 0b01011101, 0b01011110, 0b01011111:
+		in, ok = g.try(genDecode11, b) // pop REG 01011_REG
+	case 0b01110000:
+		in, ok = g.try(genDecode108, b) // jo JUMP 01110000 JUMP
+	case 0b01110001:
+		in, ok = g.try(genDecode109, b) // jno JUMP 01110001 JUMP
+	case 0b01110010:
+		in, ok = g.try(genDecode105, b) // jb JUMP 01110010 JUMP
+	case 0b01110011:
+		in, ok = g.try(genDecode114, b) // jnb JUMP 01110011 JUMP
+	case 0b01110100:
+		in, ok = g.try(genDecode102, b) // je JUMP 01110100 JUMP
+	case 0b01110101:
+		in, ok = g.try(genDecode111, b) // jne JUMP 01110101 JUMP
+	case 0b01110110:
+		in, ok = g.try(genDecode106, b) // jbe JUMP 01110110 JUMP
+	case 0b01110111:
+		in, ok = g.try(genDecode115, b) // jnbe JUMP 01110111 JUMP
+	case 0b01111000:
+		in, ok = g.try(genDecode110, b) // js JUMP 01111000 JUMP
+	case 0b01111001:
+		in, ok = g.try(genDecode117, b) // jns JUMP 01111001 JUMP
+	case 0b01111010:
+		in, ok = g.try(genDecode107, b) // jp JUMP 01111010 JUMP
+	case 0b01111011:
+		in, ok = g.try(genDecode116, b) // jnp JUMP 01111011 JUMP
+	case 0b01111100:
+		in, ok = g.try(genDecode103, b) // jl JUMP 01111100 JUMP
+	case 0b01111101:
+		in, ok = g.try(genDecode112, b) // jge JUMP 01111101 JUMP
+	case 0b01111110:
+		in, ok = g.try(genDecode104, b) // jle JUMP 01111110 JUMP
+	case 0b01111111:
+		in, ok = g.try(genDecode113, b) // jg JUMP 01111111 JUMP
+	case 0b10000000, 0b10000001:
+		in, ok = g.try(genDecode74, b) // or RM__IMM 1000000_W MOD_001_RM DISP DATAW
+		if !ok {
+			in, ok = g.try(genDecode68, b) // and RM__IMM 1000000_W MOD_100_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode77, b) // xor RM__IMM 1000000_W MOD_110_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode28, b) // add RM__IMM 100000_S_W MOD_000_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode31, b) // adc RM__IMM 100000_S_W MOD_010_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode41, b) // sbb RM__IMM 100000_S_W MOD_011_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode36, b) // sub RM__IMM 100000_S_W MOD_101_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode47, b) // cmp RM__IMM 100000_S_W MOD_111_RM DISP DATAW
+		}
+	case 0b10000010, 0b10000011:
+		in, ok = g.try(genDecode28, b) // add RM__IMM 100000_S_W MOD_000_RM DISP DATAW
+		if !ok {
+			in, ok = g.try(genDecode31, b) // adc RM__IMM 100000_S_W MOD_010_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode41, b) // sbb RM__IMM 100000_S_W MOD_011_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode36, b) // sub RM__IMM 100000_S_W MOD_101_RM DISP DATAW
+		}
+		if !ok {
+			in, ok = g.try(genDecode47, b) // cmp RM__IMM 100000_S_W MOD_111_RM DISP DATAW
+		}
+	case 0b10000100, 0b10000101:
+		in, ok = g.try(genDecode70, b) // test RM__REG 100001_D_W MOD_REG_RM DISP
+	case 0b10000110, 0b10000111:
+		in, ok = g.try(genDecode13, b) // xchg REG__RM 1000011_W MOD_REG_RM DISP
+		if !ok {
+			in, ok = g.try(genDecode70, b) // test RM__REG 100001_D_W MOD_REG_RM DISP
+		}
+	case 0b10001000, 0b10001001, 0b10001010, 0b10001011:
+		in, ok = g.try(genDecode0, b) // mov RM__REG 100010_D_W MOD_REG_RM DISP
+	case 0b10001100:
+		in, ok = g.try(genDecode6, b) // mov RM__SR 10001100 MOD_0_SR_RM DISP
+	case 0b10001101:
+		in, ok = g.try(genDecode20, b) // lea REG__RM 10001101 MOD_REG_RM DISP
+	case 0b10001110:
+		in, ok = g.try(genDecode5, b) // mov SR__RM 10001110 MOD_0_SR_RM DISP
+	case 0b10001111:
+		in, ok = g.try(genDecode10, b) // pop RM 10001111 MOD_000_RM DISP
+	case 0b10010000, 0b10010001, 0b10010010, 0b10010011, 0b10010100, 0b10010101, 0b10010110, 0b10010111:
+		in, ok = g.try(genDecode14, b) // xchg ACC__REG 10010_REG
+	case 0b10011000:
+		in, ok = g.try(genDecode57, b) // cbw 10011000
+	case 0b10011001:
+		in, ok = g.try(genDecode58, b) // cwd 10011001
+	case 0b10011010:
+		in, ok = g.try(genDecode91, b) // call FAR 10011010 FAR
+	case 0b10011011:
+		in, ok = g.try(genDecode134, b) // wait 10011011
+	case 0b10011100:
+		in, ok = g.try(genDecode25, b) // pushf 10011100
+	case 0b10011101:
+		in, ok = g.try(genDecode26, b) // popf 10011101
+	case 0b10011110:
+		in, ok = g.try(genDecode24, b) // sahf 10011110
+	case 0b10011111:
+		in, ok = g.try(genDecode23, b) // lahf 10011111
+	case 0b10100000, 0b10100001:
+		in, ok = g.try(genDecode3, b) // mov ACC__MEM 1010000_W ADDR
+	case 0b10100010, 0b10100011:
+		in, ok = g.try(genDecode4, b) // mov MEM__ACC 1010001_W ADDR
+	case 0b10100100:
+		in, ok = g.try(genDecode79, b) // movsb 10100100
+	case 0b10100101:
+		in, ok = g.try(genDecode80, b) // movsw 10100101
+	case 0b10100110:
+		in, ok = g.try(genDecode81, b) // cmpsb 10100110
+	case 0b10100111:
+		in, ok = g.try(genDecode82, b) // cmpsw 10100111
+	case 0b10101000, 0b10101001:
+		in, ok = g.try(genDecode72, b) // test ACC__IMM 1010100_W DATAW
+	case 0b10101010:
+		in, ok = g.try(genDecode87, b) // stosb 10101010
+	case 0b10101011:
+		in, ok = g.try(genDecode88, b) // stosw 10101011
+	case 0b10101100:
+		in, ok = g.try(genDecode85, b) // lodsb 10101100
+	case 0b10101101:
+		in, ok = g.try(genDecode86, b) // lodsw 10101101
+	case 0b10101110:
+		in, ok = g.try(genDecode83, b) // scasb 10101110
+	case 0b10101111:
+		in, ok = g.try(genDecode84, b) // scasw 10101111
+	case 0b10110000, 0b10110001, 0b10110010, 0b10110011, 0b10110100, 0b10110101, 0b10110110, 0b10110111, 0b10111000, 0b10111001, 0b10111010, 0b10111011, 0b10111100, 0b10111101, 0b10111110, 0b10111111:
+		in, ok = g.try(genDecode2, b) // mov REG__IMM 1011_W_REG DATAW
+	case 0b11000010:
+		in, ok = g.try(genDecode98, b) // ret DATA 11000010 DATAW
+	case 0b11000011:
+		in, ok = g.try(genDecode99, b) // ret 11000011
+	case 0b11000100:
+		in, ok = g.try(genDecode22, b) // les REG__RM 11000100 MOD_REG_RM DISP
+	case 0b11000101:
+		in, ok = g.try(genDecode21, b) // lds REG__RM 11000101 MOD_REG_RM DISP
+	case 0b11000110, 0b11000111:
+		in, ok = g.try(genDecode1, b) // mov RM__IMM 1100011_W MOD_000_RM DISP DATAW
+	case 0b11001010:
+		in, ok = g.try(genDecode100, b) // retf DATA 11001010 DATAW
+	case 0b11001011:
+		in, ok = g.try(genDecode101, b) // retf 11001011
+	case 0b11001100:
+		in, ok = g.try(genDecode123, b) // int3 11001100
+	case 0b11001101:
+		in, ok = g.try(genDecode122, b) // int DATA 11001101 DATA
+	case 0b11001110:
+		in, ok = g.try(genDecode124, b) // into 11001110
+	case 0b11001111:
+		in, ok = g.try(genDecode125, b) // iret 11001111
+	case 0b11010000, 0b11010001, 0b11010010, 0b11010011:
+		in, ok = g.try(genDecode63, b) // rol RM__V 110100_V_W MOD_000_RM DISP
+		if !ok {
+			in, ok = g.try(genDecode64, b) // ror RM__V 110100_V_W MOD_001_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode65, b) // rcl RM__V 110100_V_W MOD_010_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode66, b) // rcr RM__V 110100_V_W MOD_011_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode60, b) // shl RM__V 110100_V_W MOD_100_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode61, b) // shr RM__V 110100_V_W MOD_101_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode62, b) // sar RM__V 110100_V_W MOD_111_RM DISP
+		}
+	case 0b11010100:
+		in, ok = g.try(genDecode53, b) // aam 11010100 00001010
+	case 0b11010101:
+		in, ok = g.try(genDecode56, b) // aad 11010101 00001010
+	case 0b11010111:
+		in, ok = g.try(genDecode19, b) // xlat 11010111
+	case 0b11100000:
+		in, ok = g.try(genDecode120, b) // loopnz JUMP 11100000 JUMP
+	case 0b11100001:
+		in, ok = g.try(genDecode119, b) // loopz JUMP 11100001 JUMP
+	case 0b11100010:
+		in, ok = g.try(genDecode118, b) // loop JUMP 11100010 JUMP
+	case 0b11100011:
+		in, ok = g.try(genDecode121, b) // jcxz JUMP 11100011 JUMP
+	case 0b11100100, 0b11100101:
+		in, ok = g.try(genDecode15, b) // in ACC__DATA 1110010_W DATA
+	case 0b11100110, 0b11100111:
+		in, ok = g.try(genDecode17, b) // out DATA__ACC 1110011_W DATA
+	case 0b11101000:
+		in, ok = g.try(genDecode89, b) // call NEAR 11101000 JUMPW
+	case 0b11101001:
+		in, ok = g.try(genDecode93, b) // jmp NEAR 11101001 JUMPW
+	case 0b11101010:
+		in, ok = g.try(genDecode96, b) // jmp FAR 11101010 FAR
+	case 0b11101011:
+		in, ok = g.try(genDecode94, b) // jmp JUMP 11101011 JUMP
+	case 0b11101100, 0b11101101:
+		in, ok = g.try(genDecode16, b) // in ACC__DX 1110110_W
+	case 0b11101110, 0b11101111:
+		in, ok = g.try(genDecode18, b) // out DX__ACC 1110111_W
+	case 0b11110100:
+		in, ok = g.try(genDecode133, b) // hlt 11110100
+	case 0b11110101:
+		in, ok = g.try(genDecode127, b) // cmc 11110101
+	case 0b11110110, 0b11110111:
+		in, ok = g.try(genDecode71, b) // test RM__IMM 1111011_W MOD_000_RM DISP DATAW
+		if !ok {
+			in, ok = g.try(genDecode59, b) // not RM 1111011_W MOD_010_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode45, b) // neg RM 1111011_W MOD_011_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode51, b) // mul RM 1111011_W MOD_100_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode52, b) // imul RM 1111011_W MOD_101_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode54, b) // div RM 1111011_W MOD_110_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode55, b) // idiv RM 1111011_W MOD_111_RM DISP
+		}
+	case 0b11111000:
+		in, ok = g.try(genDecode126, b) // clc 11111000
+	case 0b11111001:
+		in, ok = g.try(genDecode128, b) // stc 11111001
+	case 0b11111010:
+		in, ok = g.try(genDecode131, b) // cli 11111010
+	case 0b11111011:
+		in, ok = g.try(genDecode132, b) // sti 11111011
+	case 0b11111100:
+		in, ok = g.try(genDecode129, b) // cld 11111100
+	case 0b11111101:
+		in, ok = g.try(genDecode130, b) // std 11111101
+	case 0b11111110:
+		in, ok = g.try(genDecode33, b) // inc RM 1111111_W MOD_000_RM DISP
+		if !ok {
+			in, ok = g.try(genDecode43, b) // dec RM 1111111_W MOD_001_RM DISP
+		}
+	case 0b11111111:
+		in, ok = g.try(genDecode90, b) // call RM 11111111 MOD_010_RM DISP
+		if !ok {
+			in, ok = g.try(genDecode92, b) // call FARRM 11111111 MOD_011_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode95, b) // jmp RM 11111111 MOD_100_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode97, b) // jmp FARRM 11111111 MOD_101_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode7, b) // push RM 11111111 MOD_110_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode33, b) // inc RM 1111111_W MOD_000_RM DISP
+		}
+		if !ok {
+			in, ok = g.try(genDecode43, b) // dec RM 1111111_W MOD_001_RM DISP
+		}
+	default:
+		return Instruction{}, g.decodeError(start, ReasonUnknownOpcode)
+	}
+	if !ok {
+		if g.anyTruncated {
+			return Instruction{}, g.decodeError(start, ReasonTruncated)
+		}
+		return Instruction{}, g.decodeError(start, ReasonConstantMismatch)
+	}
+	in.Offset = start
+	in.Length = g.di - start
+	in.Flags = flags
+	if in.FlagSet(FlagLock) && !in.lockable() {
+		return Instruction{}, g.decodeError(start, ReasonInvalidLock)
+	}
+	return in, nil
+}
+
+// mov RM__REG 100010_D_W MOD_REG_RM DISP
+func genDecode0(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "RM__REG",
+		Opcode:   0b100010,
+		W:        1,
+		Encoding: &encoder.encodings[0],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// mov RM__IMM 1100011_W MOD_000_RM DISP DATAW
+func genDecode1(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "RM__IMM",
+		Opcode:   0b1100011,
+		W:        1,
+		Encoding: &encoder.encodings[1],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b000 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// mov REG__IMM 1011_W_REG DATAW
+func genDecode2(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "REG__IMM",
+		Opcode:   0b1011,
+		W:        1,
+		Encoding: &encoder.encodings[2],
+	}
+	in.W = (b >> 3) & 0b1
+	in.Reg = (b >> 0) & 0b111
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// mov ACC__MEM 1010000_W ADDR
+func genDecode3(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "ACC__MEM",
+		Opcode:   0b1010000,
+		W:        1,
+		Encoding: &encoder.encodings[3],
+	}
+	in.W = (b >> 0) & 0b1
+	in.Data = g.imm16()
+	return in, true
+}
+
+// mov MEM__ACC 1010001_W ADDR
+func genDecode4(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "MEM__ACC",
+		Opcode:   0b1010001,
+		W:        1,
+		Encoding: &encoder.encodings[4],
+	}
+	in.W = (b >> 0) & 0b1
+	in.Data = g.imm16()
+	return in, true
+}
+
+// mov SR__RM 10001110 MOD_0_SR_RM DISP
+func genDecode5(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "SR__RM",
+		Opcode:   0b10001110,
+		W:        1,
+		Encoding: &encoder.encodings[5],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>5)&0b1 != 0b0 {
+		return Instruction{}, false
+	}
+	in.SR = (b >> 3) & 0b11
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// mov RM__SR 10001100 MOD_0_SR_RM DISP
+func genDecode6(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mov",
+		Type:     "RM__SR",
+		Opcode:   0b10001100,
+		W:        1,
+		Encoding: &encoder.encodings[6],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>5)&0b1 != 0b0 {
+		return Instruction{}, false
+	}
+	in.SR = (b >> 3) & 0b11
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// push RM 11111111 MOD_110_RM DISP
+func genDecode7(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "push",
+		Type:     "RM",
+		Opcode:   0b11111111,
+		W:        1,
+		Encoding: &encoder.encodings[7],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b110 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// push REG 01010_REG
+func genDecode8(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "push",
+		Type:     "REG",
+		Opcode:   0b01010,
+		W:        1,
+		Encoding: &encoder.encodings[8],
+	}
+	in.Reg = (b >> 0) & 0b111
+	return in, true
+}
+
+// push SR 000_SR_110
+func genDecode9(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "push",
+		Type:     "SR",
+		Opcode:   0b000,
+		W:        1,
+		Encoding: &encoder.encodings[9],
+	}
+	in.SR = (b >> 3) & 0b11
+	return in, true
+}
+
+// pop RM 10001111 MOD_000_RM DISP
+func genDecode10(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "pop",
+		Type:     "RM",
+		Opcode:   0b10001111,
+		W:        1,
+		Encoding: &encoder.encodings[10],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b000 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// pop REG 01011_REG
+func genDecode11(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "pop",
+		Type:     "REG",
+		Opcode:   0b01011,
+		W:        1,
+		Encoding: &encoder.encodings[11],
+	}
+	in.Reg = (b >> 0) & 0b111
+	return in, true
+}
+
+// pop SR 000_SR_111
+func genDecode12(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "pop",
+		Type:     "SR",
+		Opcode:   0b000,
+		W:        1,
+		Encoding: &encoder.encodings[12],
+	}
+	in.SR = (b >> 3) & 0b11
+	return in, true
+}
+
+// xchg REG__RM 1000011_W MOD_REG_RM DISP
+func genDecode13(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "xchg",
+		Type:     "REG__RM",
+		Opcode:   0b1000011,
+		W:        1,
+		Encoding: &encoder.encodings[13],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// xchg ACC__REG 10010_REG
+func genDecode14(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "xchg",
+		Type:     "ACC__REG",
+		Opcode:   0b10010,
+		W:        1,
+		Encoding: &encoder.encodings[14],
+	}
+	in.Reg = (b >> 0) & 0b111
+	return in, true
+}
+
+// in ACC__DATA 1110010_W DATA
+func genDecode15(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "in",
+		Type:     "ACC__DATA",
+		Opcode:   0b1110010,
+		W:        1,
+		Encoding: &encoder.encodings[15],
+	}
+	in.W = (b >> 0) & 0b1
+	in.Data = uint16(g.next())
+	return in, true
+}
+
+// in ACC__DX 1110110_W
+func genDecode16(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "in",
+		Type:     "ACC__DX",
+		Opcode:   0b1110110,
+		W:        1,
+		Encoding: &encoder.encodings[16],
+	}
+	in.W = (b >> 0) & 0b1
+	return in, true
+}
+
+// out DATA__ACC 1110011_W DATA
+func genDecode17(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "out",
+		Type:     "DATA__ACC",
+		Opcode:   0b1110011,
+		W:        1,
+		Encoding: &encoder.encodings[17],
+	}
+	in.W = (b >> 0) & 0b1
+	in.Data = uint16(g.next())
+	return in, true
+}
+
+// out DX__ACC 1110111_W
+func genDecode18(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "out",
+		Type:     "DX__ACC",
+		Opcode:   0b1110111,
+		W:        1,
+		Encoding: &encoder.encodings[18],
+	}
+	in.W = (b >> 0) & 0b1
+	return in, true
+}
+
+// xlat 11010111
+func genDecode19(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "xlat",
+		Type:     "",
+		Opcode:   0b11010111,
+		W:        1,
+		Encoding: &encoder.encodings[19],
+	}
+	return in, true
+}
+
+// lea REG__RM 10001101 MOD_REG_RM DISP
+func genDecode20(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "lea",
+		Type:     "REG__RM",
+		Opcode:   0b10001101,
+		W:        1,
+		Encoding: &encoder.encodings[20],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// lds REG__RM 11000101 MOD_REG_RM DISP
+func genDecode21(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "lds",
+		Type:     "REG__RM",
+		Opcode:   0b11000101,
+		W:        1,
+		Encoding: &encoder.encodings[21],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// les REG__RM 11000100 MOD_REG_RM DISP
+func genDecode22(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "les",
+		Type:     "REG__RM",
+		Opcode:   0b11000100,
+		W:        1,
+		Encoding: &encoder.encodings[22],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// lahf 10011111
+func genDecode23(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "lahf",
+		Type:     "",
+		Opcode:   0b10011111,
+		W:        1,
+		Encoding: &encoder.encodings[23],
+	}
+	return in, true
+}
+
+// sahf 10011110
+func genDecode24(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sahf",
+		Type:     "",
+		Opcode:   0b10011110,
+		W:        1,
+		Encoding: &encoder.encodings[24],
+	}
+	return in, true
+}
+
+// pushf 10011100
+func genDecode25(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "pushf",
+		Type:     "",
+		Opcode:   0b10011100,
+		W:        1,
+		Encoding: &encoder.encodings[25],
+	}
+	return in, true
+}
+
+// popf 10011101
+func genDecode26(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "popf",
+		Type:     "",
+		Opcode:   0b10011101,
+		W:        1,
+		Encoding: &encoder.encodings[26],
+	}
+	return in, true
+}
+
+// add RM__REG 000000_D_W MOD_REG_RM DISP
+func genDecode27(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "add",
+		Type:     "RM__REG",
+		Opcode:   0b000000,
+		W:        1,
+		Encoding: &encoder.encodings[27],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// add RM__IMM 100000_S_W MOD_000_RM DISP DATAW
+func genDecode28(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "add",
+		Type:     "RM__IMM",
+		Opcode:   0b100000,
+		W:        1,
+		Encoding: &encoder.encodings[28],
+	}
+	in.S = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b000 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// add ACC__IMM 0000010_W DATAW
+func genDecode29(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "add",
+		Type:     "ACC__IMM",
+		Opcode:   0b0000010,
+		W:        1,
+		Encoding: &encoder.encodings[29],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// adc RM__REG 000100_D_W MOD_REG_RM DISP
+func genDecode30(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "adc",
+		Type:     "RM__REG",
+		Opcode:   0b000100,
+		W:        1,
+		Encoding: &encoder.encodings[30],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// adc RM__IMM 100000_S_W MOD_010_RM DISP DATAW
+func genDecode31(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "adc",
+		Type:     "RM__IMM",
+		Opcode:   0b100000,
+		W:        1,
+		Encoding: &encoder.encodings[31],
+	}
+	in.S = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b010 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// adc ACC__IMM 0001010_W DATAW
+func genDecode32(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "adc",
+		Type:     "ACC__IMM",
+		Opcode:   0b0001010,
+		W:        1,
+		Encoding: &encoder.encodings[32],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// inc RM 1111111_W MOD_000_RM DISP
+func genDecode33(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "inc",
+		Type:     "RM",
+		Opcode:   0b1111111,
+		W:        1,
+		Encoding: &encoder.encodings[33],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b000 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// inc REG 01000_REG
+func genDecode34(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "inc",
+		Type:     "REG",
+		Opcode:   0b01000,
+		W:        1,
+		Encoding: &encoder.encodings[34],
+	}
+	in.Reg = (b >> 0) & 0b111
+	return in, true
+}
+
+// sub RM__REG 001010_D_W MOD_REG_RM DISP
+func genDecode35(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sub",
+		Type:     "RM__REG",
+		Opcode:   0b001010,
+		W:        1,
+		Encoding: &encoder.encodings[35],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// sub RM__IMM 100000_S_W MOD_101_RM DISP DATAW
+func genDecode36(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sub",
+		Type:     "RM__IMM",
+		Opcode:   0b100000,
+		W:        1,
+		Encoding: &encoder.encodings[36],
+	}
+	in.S = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b101 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// sub ACC__IMM 0010110_W DATAW
+func genDecode37(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sub",
+		Type:     "ACC__IMM",
+		Opcode:   0b0010110,
+		W:        1,
+		Encoding: &encoder.encodings[37],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// aaa 00110111
+func genDecode38(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "aaa",
+		Type:     "",
+		Opcode:   0b00110111,
+		W:        1,
+		Encoding: &encoder.encodings[38],
+	}
+	return in, true
+}
+
+// daa 00100111
+func genDecode39(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "daa",
+		Type:     "",
+		Opcode:   0b00100111,
+		W:        1,
+		Encoding: &encoder.encodings[39],
+	}
+	return in, true
+}
+
+// sbb RM__REG 000110_D_W MOD_REG_RM DISP
+func genDecode40(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sbb",
+		Type:     "RM__REG",
+		Opcode:   0b000110,
+		W:        1,
+		Encoding: &encoder.encodings[40],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// sbb RM__IMM 100000_S_W MOD_011_RM DISP DATAW
+func genDecode41(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sbb",
+		Type:     "RM__IMM",
+		Opcode:   0b100000,
+		W:        1,
+		Encoding: &encoder.encodings[41],
+	}
+	in.S = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b011 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// sbb ACC__IMM 0001110_W DATAW
+func genDecode42(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sbb",
+		Type:     "ACC__IMM",
+		Opcode:   0b0001110,
+		W:        1,
+		Encoding: &encoder.encodings[42],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// dec RM 1111111_W MOD_001_RM DISP
+func genDecode43(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "dec",
+		Type:     "RM",
+		Opcode:   0b1111111,
+		W:        1,
+		Encoding: &encoder.encodings[43],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b001 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// dec REG 01001_REG
+func genDecode44(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "dec",
+		Type:     "REG",
+		Opcode:   0b01001,
+		W:        1,
+		Encoding: &encoder.encodings[44],
+	}
+	in.Reg = (b >> 0) & 0b111
+	return in, true
+}
+
+// neg RM 1111011_W MOD_011_RM DISP
+func genDecode45(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "neg",
+		Type:     "RM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[45],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b011 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// cmp RM__REG 001110_D_W MOD_REG_RM DISP
+func genDecode46(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cmp",
+		Type:     "RM__REG",
+		Opcode:   0b001110,
+		W:        1,
+		Encoding: &encoder.encodings[46],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// cmp RM__IMM 100000_S_W MOD_111_RM DISP DATAW
+func genDecode47(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cmp",
+		Type:     "RM__IMM",
+		Opcode:   0b100000,
+		W:        1,
+		Encoding: &encoder.encodings[47],
+	}
+	in.S = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b111 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// cmp ACC__IMM 0011110_W DATAW
+func genDecode48(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cmp",
+		Type:     "ACC__IMM",
+		Opcode:   0b0011110,
+		W:        1,
+		Encoding: &encoder.encodings[48],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// aas 00111111
+func genDecode49(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "aas",
+		Type:     "",
+		Opcode:   0b00111111,
+		W:        1,
+		Encoding: &encoder.encodings[49],
+	}
+	return in, true
+}
+
+// das 00101111
+func genDecode50(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "das",
+		Type:     "",
+		Opcode:   0b00101111,
+		W:        1,
+		Encoding: &encoder.encodings[50],
+	}
+	return in, true
+}
+
+// mul RM 1111011_W MOD_100_RM DISP
+func genDecode51(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "mul",
+		Type:     "RM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[51],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b100 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// imul RM 1111011_W MOD_101_RM DISP
+func genDecode52(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "imul",
+		Type:     "RM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[52],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b101 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// aam 11010100 00001010
+func genDecode53(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "aam",
+		Type:     "",
+		Opcode:   0b11010100,
+		W:        1,
+		Encoding: &encoder.encodings[53],
+	}
+	b = g.next()
+	if (b>>0)&0b11111111 != 0b00001010 {
+		return Instruction{}, false
+	}
+	return in, true
+}
+
+// div RM 1111011_W MOD_110_RM DISP
+func genDecode54(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "div",
+		Type:     "RM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[54],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b110 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// idiv RM 1111011_W MOD_111_RM DISP
+func genDecode55(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "idiv",
+		Type:     "RM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[55],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b111 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// aad 11010101 00001010
+func genDecode56(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "aad",
+		Type:     "",
+		Opcode:   0b11010101,
+		W:        1,
+		Encoding: &encoder.encodings[56],
+	}
+	b = g.next()
+	if (b>>0)&0b11111111 != 0b00001010 {
+		return Instruction{}, false
+	}
+	return in, true
+}
+
+// cbw 10011000
+func genDecode57(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cbw",
+		Type:     "",
+		Opcode:   0b10011000,
+		W:        1,
+		Encoding: &encoder.encodings[57],
+	}
+	return in, true
+}
+
+// cwd 10011001
+func genDecode58(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cwd",
+		Type:     "",
+		Opcode:   0b10011001,
+		W:        1,
+		Encoding: &encoder.encodings[58],
+	}
+	return in, true
+}
+
+// not RM 1111011_W MOD_010_RM DISP
+func genDecode59(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "not",
+		Type:     "RM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[59],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b010 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// shl RM__V 110100_V_W MOD_100_RM DISP
+func genDecode60(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "shl",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[60],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b100 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// shr RM__V 110100_V_W MOD_101_RM DISP
+func genDecode61(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "shr",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[61],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b101 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// sar RM__V 110100_V_W MOD_111_RM DISP
+func genDecode62(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sar",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[62],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b111 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// rol RM__V 110100_V_W MOD_000_RM DISP
+func genDecode63(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "rol",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[63],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b000 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// ror RM__V 110100_V_W MOD_001_RM DISP
+func genDecode64(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "ror",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[64],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b001 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// rcl RM__V 110100_V_W MOD_010_RM DISP
+func genDecode65(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "rcl",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[65],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b010 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// rcr RM__V 110100_V_W MOD_011_RM DISP
+func genDecode66(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "rcr",
+		Type:     "RM__V",
+		Opcode:   0b110100,
+		W:        1,
+		Encoding: &encoder.encodings[66],
+	}
+	in.V = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b011 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// and RM__REG 001000_D_W MOD_REG_RM DISP
+func genDecode67(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "and",
+		Type:     "RM__REG",
+		Opcode:   0b001000,
+		W:        1,
+		Encoding: &encoder.encodings[67],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// and RM__IMM 1000000_W MOD_100_RM DISP DATAW
+func genDecode68(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "and",
+		Type:     "RM__IMM",
+		Opcode:   0b1000000,
+		W:        1,
+		Encoding: &encoder.encodings[68],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b100 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// and ACC__IMM 0010010_W DATAW
+func genDecode69(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "and",
+		Type:     "ACC__IMM",
+		Opcode:   0b0010010,
+		W:        1,
+		Encoding: &encoder.encodings[69],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// test RM__REG 100001_D_W MOD_REG_RM DISP
+func genDecode70(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "test",
+		Type:     "RM__REG",
+		Opcode:   0b100001,
+		W:        1,
+		Encoding: &encoder.encodings[70],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// test RM__IMM 1111011_W MOD_000_RM DISP DATAW
+func genDecode71(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "test",
+		Type:     "RM__IMM",
+		Opcode:   0b1111011,
+		W:        1,
+		Encoding: &encoder.encodings[71],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b000 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// test ACC__IMM 1010100_W DATAW
+func genDecode72(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "test",
+		Type:     "ACC__IMM",
+		Opcode:   0b1010100,
+		W:        1,
+		Encoding: &encoder.encodings[72],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// or RM__REG 000010_D_W MOD_REG_RM DISP
+func genDecode73(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "or",
+		Type:     "RM__REG",
+		Opcode:   0b000010,
+		W:        1,
+		Encoding: &encoder.encodings[73],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// or RM__IMM 1000000_W MOD_001_RM DISP DATAW
+func genDecode74(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "or",
+		Type:     "RM__IMM",
+		Opcode:   0b1000000,
+		W:        1,
+		Encoding: &encoder.encodings[74],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b001 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// or ACC__IMM 0000110_W DATAW
+func genDecode75(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "or",
+		Type:     "ACC__IMM",
+		Opcode:   0b0000110,
+		W:        1,
+		Encoding: &encoder.encodings[75],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// xor RM__REG 001100_D_W MOD_REG_RM DISP
+func genDecode76(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "xor",
+		Type:     "RM__REG",
+		Opcode:   0b001100,
+		W:        1,
+		Encoding: &encoder.encodings[76],
+	}
+	in.D = (b >> 1) & 0b1
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	in.Reg = (b >> 3) & 0b111
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// xor RM__IMM 1000000_W MOD_110_RM DISP DATAW
+func genDecode77(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "xor",
+		Type:     "RM__IMM",
+		Opcode:   0b1000000,
+		W:        1,
+		Encoding: &encoder.encodings[77],
+	}
+	in.W = (b >> 0) & 0b1
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b110 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// xor ACC__IMM 0011010_W DATAW
+func genDecode78(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "xor",
+		Type:     "ACC__IMM",
+		Opcode:   0b0011010,
+		W:        1,
+		Encoding: &encoder.encodings[78],
+	}
+	in.W = (b >> 0) & 0b1
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// movsb 10100100
+func genDecode79(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "movsb",
+		Type:     "",
+		Opcode:   0b10100100,
+		W:        1,
+		Encoding: &encoder.encodings[79],
+	}
+	return in, true
+}
+
+// movsw 10100101
+func genDecode80(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "movsw",
+		Type:     "",
+		Opcode:   0b10100101,
+		W:        1,
+		Encoding: &encoder.encodings[80],
+	}
+	return in, true
+}
+
+// cmpsb 10100110
+func genDecode81(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cmpsb",
+		Type:     "",
+		Opcode:   0b10100110,
+		W:        1,
+		Encoding: &encoder.encodings[81],
+	}
+	return in, true
+}
+
+// cmpsw 10100111
+func genDecode82(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cmpsw",
+		Type:     "",
+		Opcode:   0b10100111,
+		W:        1,
+		Encoding: &encoder.encodings[82],
+	}
+	return in, true
+}
+
+// scasb 10101110
+func genDecode83(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "scasb",
+		Type:     "",
+		Opcode:   0b10101110,
+		W:        1,
+		Encoding: &encoder.encodings[83],
+	}
+	return in, true
+}
+
+// scasw 10101111
+func genDecode84(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "scasw",
+		Type:     "",
+		Opcode:   0b10101111,
+		W:        1,
+		Encoding: &encoder.encodings[84],
+	}
+	return in, true
+}
+
+// lodsb 10101100
+func genDecode85(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "lodsb",
+		Type:     "",
+		Opcode:   0b10101100,
+		W:        1,
+		Encoding: &encoder.encodings[85],
+	}
+	return in, true
+}
+
+// lodsw 10101101
+func genDecode86(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "lodsw",
+		Type:     "",
+		Opcode:   0b10101101,
+		W:        1,
+		Encoding: &encoder.encodings[86],
+	}
+	return in, true
+}
+
+// stosb 10101010
+func genDecode87(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "stosb",
+		Type:     "",
+		Opcode:   0b10101010,
+		W:        1,
+		Encoding: &encoder.encodings[87],
+	}
+	return in, true
+}
+
+// stosw 10101011
+func genDecode88(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "stosw",
+		Type:     "",
+		Opcode:   0b10101011,
+		W:        1,
+		Encoding: &encoder.encodings[88],
+	}
+	return in, true
+}
+
+// call NEAR 11101000 JUMPW
+func genDecode89(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "call",
+		Type:     "NEAR",
+		Opcode:   0b11101000,
+		W:        1,
+		Encoding: &encoder.encodings[89],
+	}
+	in.JumpTarget = int16(g.imm16())
+	return in, true
+}
+
+// call RM 11111111 MOD_010_RM DISP
+func genDecode90(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "call",
+		Type:     "RM",
+		Opcode:   0b11111111,
+		W:        1,
+		Encoding: &encoder.encodings[90],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b010 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// call FAR 10011010 FAR
+func genDecode91(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "call",
+		Type:     "FAR",
+		Opcode:   0b10011010,
+		W:        1,
+		Encoding: &encoder.encodings[91],
+	}
+	in.Data = g.imm16()
+	in.Segment = g.imm16()
+	return in, true
+}
+
+// call FARRM 11111111 MOD_011_RM DISP
+func genDecode92(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "call",
+		Type:     "FARRM",
+		Opcode:   0b11111111,
+		W:        1,
+		Encoding: &encoder.encodings[92],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b011 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	// A far pointer can't be loaded from a register
+	if in.Mod == 0b11 {
+		return Instruction{}, false
+	}
+	return in, true
+}
+
+// jmp NEAR 11101001 JUMPW
+func genDecode93(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jmp",
+		Type:     "NEAR",
+		Opcode:   0b11101001,
+		W:        1,
+		Encoding: &encoder.encodings[93],
+	}
+	in.JumpTarget = int16(g.imm16())
+	return in, true
+}
+
+// jmp JUMP 11101011 JUMP
+func genDecode94(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jmp",
+		Type:     "JUMP",
+		Opcode:   0b11101011,
+		W:        1,
+		Encoding: &encoder.encodings[94],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jmp RM 11111111 MOD_100_RM DISP
+func genDecode95(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jmp",
+		Type:     "RM",
+		Opcode:   0b11111111,
+		W:        1,
+		Encoding: &encoder.encodings[95],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b100 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	return in, true
+}
+
+// jmp FAR 11101010 FAR
+func genDecode96(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jmp",
+		Type:     "FAR",
+		Opcode:   0b11101010,
+		W:        1,
+		Encoding: &encoder.encodings[96],
+	}
+	in.Data = g.imm16()
+	in.Segment = g.imm16()
+	return in, true
+}
+
+// jmp FARRM 11111111 MOD_101_RM DISP
+func genDecode97(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jmp",
+		Type:     "FARRM",
+		Opcode:   0b11111111,
+		W:        1,
+		Encoding: &encoder.encodings[97],
+	}
+	b = g.next()
+	in.Mod = (b >> 6) & 0b11
+	if (b>>3)&0b111 != 0b101 {
+		return Instruction{}, false
+	}
+	in.RM = (b >> 0) & 0b111
+	g.disp(&in)
+	// A far pointer can't be loaded from a register
+	if in.Mod == 0b11 {
+		return Instruction{}, false
+	}
+	return in, true
+}
+
+// ret DATA 11000010 DATAW
+func genDecode98(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "ret",
+		Type:     "DATA",
+		Opcode:   0b11000010,
+		W:        1,
+		Encoding: &encoder.encodings[98],
+	}
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// ret 11000011
+func genDecode99(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "ret",
+		Type:     "",
+		Opcode:   0b11000011,
+		W:        1,
+		Encoding: &encoder.encodings[99],
+	}
+	return in, true
+}
+
+// retf DATA 11001010 DATAW
+func genDecode100(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "retf",
+		Type:     "DATA",
+		Opcode:   0b11001010,
+		W:        1,
+		Encoding: &encoder.encodings[100],
+	}
+	switch {
+	case in.W > 0 && in.S == 0:
+		in.Data = g.imm16()
+	case in.W > 0:
+		in.Data = uint16(int8(g.next()))
+	default:
+		in.Data = uint16(g.next())
+	}
+	return in, true
+}
+
+// retf 11001011
+func genDecode101(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "retf",
+		Type:     "",
+		Opcode:   0b11001011,
+		W:        1,
+		Encoding: &encoder.encodings[101],
+	}
+	return in, true
+}
+
+// je JUMP 01110100 JUMP
+func genDecode102(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "je",
+		Type:     "JUMP",
+		Opcode:   0b01110100,
+		W:        1,
+		Encoding: &encoder.encodings[102],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jl JUMP 01111100 JUMP
+func genDecode103(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jl",
+		Type:     "JUMP",
+		Opcode:   0b01111100,
+		W:        1,
+		Encoding: &encoder.encodings[103],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jle JUMP 01111110 JUMP
+func genDecode104(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jle",
+		Type:     "JUMP",
+		Opcode:   0b01111110,
+		W:        1,
+		Encoding: &encoder.encodings[104],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jb JUMP 01110010 JUMP
+func genDecode105(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jb",
+		Type:     "JUMP",
+		Opcode:   0b01110010,
+		W:        1,
+		Encoding: &encoder.encodings[105],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jbe JUMP 01110110 JUMP
+func genDecode106(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jbe",
+		Type:     "JUMP",
+		Opcode:   0b01110110,
+		W:        1,
+		Encoding: &encoder.encodings[106],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jp JUMP 01111010 JUMP
+func genDecode107(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jp",
+		Type:     "JUMP",
+		Opcode:   0b01111010,
+		W:        1,
+		Encoding: &encoder.encodings[107],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jo JUMP 01110000 JUMP
+func genDecode108(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jo",
+		Type:     "JUMP",
+		Opcode:   0b01110000,
+		W:        1,
+		Encoding: &encoder.encodings[108],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jno JUMP 01110001 JUMP
+func genDecode109(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jno",
+		Type:     "JUMP",
+		Opcode:   0b01110001,
+		W:        1,
+		Encoding: &encoder.encodings[109],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// js JUMP 01111000 JUMP
+func genDecode110(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "js",
+		Type:     "JUMP",
+		Opcode:   0b01111000,
+		W:        1,
+		Encoding: &encoder.encodings[110],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jne JUMP 01110101 JUMP
+func genDecode111(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jne",
+		Type:     "JUMP",
+		Opcode:   0b01110101,
+		W:        1,
+		Encoding: &encoder.encodings[111],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jge JUMP 01111101 JUMP
+func genDecode112(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jge",
+		Type:     "JUMP",
+		Opcode:   0b01111101,
+		W:        1,
+		Encoding: &encoder.encodings[112],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jg JUMP 01111111 JUMP
+func genDecode113(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jg",
+		Type:     "JUMP",
+		Opcode:   0b01111111,
+		W:        1,
+		Encoding: &encoder.encodings[113],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jnb JUMP 01110011 JUMP
+func genDecode114(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jnb",
+		Type:     "JUMP",
+		Opcode:   0b01110011,
+		W:        1,
+		Encoding: &encoder.encodings[114],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jnbe JUMP 01110111 JUMP
+func genDecode115(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jnbe",
+		Type:     "JUMP",
+		Opcode:   0b01110111,
+		W:        1,
+		Encoding: &encoder.encodings[115],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jnp JUMP 01111011 JUMP
+func genDecode116(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jnp",
+		Type:     "JUMP",
+		Opcode:   0b01111011,
+		W:        1,
+		Encoding: &encoder.encodings[116],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jns JUMP 01111001 JUMP
+func genDecode117(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jns",
+		Type:     "JUMP",
+		Opcode:   0b01111001,
+		W:        1,
+		Encoding: &encoder.encodings[117],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// loop JUMP 11100010 JUMP
+func genDecode118(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "loop",
+		Type:     "JUMP",
+		Opcode:   0b11100010,
+		W:        1,
+		Encoding: &encoder.encodings[118],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// loopz JUMP 11100001 JUMP
+func genDecode119(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "loopz",
+		Type:     "JUMP",
+		Opcode:   0b11100001,
+		W:        1,
+		Encoding: &encoder.encodings[119],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// loopnz JUMP 11100000 JUMP
+func genDecode120(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "loopnz",
+		Type:     "JUMP",
+		Opcode:   0b11100000,
+		W:        1,
+		Encoding: &encoder.encodings[120],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// jcxz JUMP 11100011 JUMP
+func genDecode121(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "jcxz",
+		Type:     "JUMP",
+		Opcode:   0b11100011,
+		W:        1,
+		Encoding: &encoder.encodings[121],
+	}
+	in.JumpTarget = int16(int8(g.next()))
+	return in, true
+}
+
+// int DATA 11001101 DATA
+func genDecode122(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "int",
+		Type:     "DATA",
+		Opcode:   0b11001101,
+		W:        1,
+		Encoding: &encoder.encodings[122],
+	}
+	in.Data = uint16(g.next())
+	return in, true
+}
+
+// int3 11001100
+func genDecode123(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "int3",
+		Type:     "",
+		Opcode:   0b11001100,
+		W:        1,
+		Encoding: &encoder.encodings[123],
+	}
+	return in, true
+}
+
+// into 11001110
+func genDecode124(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "into",
+		Type:     "",
+		Opcode:   0b11001110,
+		W:        1,
+		Encoding: &encoder.encodings[124],
+	}
+	return in, true
+}
+
+// iret 11001111
+func genDecode125(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "iret",
+		Type:     "",
+		Opcode:   0b11001111,
+		W:        1,
+		Encoding: &encoder.encodings[125],
+	}
+	return in, true
+}
+
+// clc 11111000
+func genDecode126(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "clc",
+		Type:     "",
+		Opcode:   0b11111000,
+		W:        1,
+		Encoding: &encoder.encodings[126],
+	}
+	return in, true
+}
+
+// cmc 11110101
+func genDecode127(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cmc",
+		Type:     "",
+		Opcode:   0b11110101,
+		W:        1,
+		Encoding: &encoder.encodings[127],
+	}
+	return in, true
+}
+
+// stc 11111001
+func genDecode128(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "stc",
+		Type:     "",
+		Opcode:   0b11111001,
+		W:        1,
+		Encoding: &encoder.encodings[128],
+	}
+	return in, true
+}
+
+// cld 11111100
+func genDecode129(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cld",
+		Type:     "",
+		Opcode:   0b11111100,
+		W:        1,
+		Encoding: &encoder.encodings[129],
+	}
+	return in, true
+}
+
+// std 11111101
+func genDecode130(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "std",
+		Type:     "",
+		Opcode:   0b11111101,
+		W:        1,
+		Encoding: &encoder.encodings[130],
+	}
+	return in, true
+}
+
+// cli 11111010
+func genDecode131(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "cli",
+		Type:     "",
+		Opcode:   0b11111010,
+		W:        1,
+		Encoding: &encoder.encodings[131],
+	}
+	return in, true
+}
+
+// sti 11111011
+func genDecode132(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "sti",
+		Type:     "",
+		Opcode:   0b11111011,
+		W:        1,
+		Encoding: &encoder.encodings[132],
+	}
+	return in, true
+}
+
+// hlt 11110100
+func genDecode133(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "hlt",
+		Type:     "",
+		Opcode:   0b11110100,
+		W:        1,
+		Encoding: &encoder.encodings[133],
+	}
+	return in, true
+}
+
+// wait 10011011
+func genDecode134(g *genDecoder, b byte) (Instruction, bool) {
+	in := Instruction{
+		Name:     "wait",
+		Type:     "",
+		Opcode:   0b10011011,
+		W:        1,
+		Encoding: &encoder.encodings[134],
+	}
+	return in, true
+}
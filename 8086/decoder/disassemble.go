@@ -0,0 +1,495 @@
+package decoder
+
+//go:generate go run ../cmd/codegen -input instruction_encodings.txt -output disassemble.gen.go
+
+import (
+	"fmt"
+	"strings"
+)
+
+type disassembler struct {
+	data []byte
+	di   int
+
+	curByte byte
+	cbi     int
+
+	// truncated is set when a read runs past the end of data
+	truncated bool
+}
+
+// DecodeErrorReason describes why an instruction couldn't be decoded.
+type DecodeErrorReason int
+
+const (
+	ReasonUnknownOpcode DecodeErrorReason = iota + 1
+	ReasonTruncated
+	ReasonConstantMismatch
+	ReasonDuplicatePrefix
+	ReasonConflictingPrefix
+	ReasonInvalidLock
+)
+
+func (r DecodeErrorReason) String() string {
+	switch r {
+	case ReasonUnknownOpcode:
+		return "unknown opcode"
+	case ReasonTruncated:
+		return "truncated operand"
+	case ReasonConstantMismatch:
+		return "constant mismatch"
+	case ReasonDuplicatePrefix:
+		return "duplicate prefix"
+	case ReasonConflictingPrefix:
+		return "conflicting prefix"
+	case ReasonInvalidLock:
+		return "lock prefix on an instruction that can't be locked"
+	}
+	return fmt.Sprintf("DecodeErrorReason(%d)", int(r))
+}
+
+// DecodeError is returned when the bytes at Offset don't decode to a known
+// instruction.
+type DecodeError struct {
+	Offset int
+	Bytes  []byte
+	Reason DecodeErrorReason
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unable to decode % x at offset %d: %s", e.Bytes, e.Offset, e.Reason)
+}
+
+// nextInstruction decodes the instruction at d.di. On error d.di is left at the
+// start of the failed instruction so the caller can decide how to resync.
+func (d *disassembler) nextInstruction() (Instruction, error) {
+	start := d.di
+	d.truncated = false
+	b := d.next()
+
+	var flags InstructionFlags
+
+	// Look for any prefix instructions
+	for p := prefix(b); p != 0; p = prefix(b) {
+		var reason DecodeErrorReason
+		if flags, reason = addPrefix(flags, p); reason != 0 {
+			return Instruction{}, d.decodeError(start, reason)
+		}
+		b = d.next()
+	}
+	if d.truncated {
+		return Instruction{}, d.decodeError(start, ReasonTruncated)
+	}
+
+	encs := encoder.Decode(b)
+	if len(encs) == 0 {
+		return Instruction{}, d.decodeError(start, ReasonUnknownOpcode)
+	}
+
+	truncated := false
+	for _, enc := range encs {
+		d.truncated = false
+		if in, ok := d.parse(enc); ok {
+			in.Offset = start
+			in.Length = d.di - start
+			in.Flags = flags
+			if in.FlagSet(FlagLock) && !in.lockable() {
+				return Instruction{}, d.decodeError(start, ReasonInvalidLock)
+			}
+			return in, nil
+		}
+		truncated = truncated || d.truncated
+	}
+	if truncated {
+		return Instruction{}, d.decodeError(start, ReasonTruncated)
+	}
+	return Instruction{}, d.decodeError(start, ReasonConstantMismatch)
+}
+
+// decodeError builds a DecodeError for the instruction starting at start and
+// rewinds to it.
+func (d *disassembler) decodeError(start int, reason DecodeErrorReason) error {
+	end := d.di
+	if reason == ReasonTruncated {
+		end = len(d.data)
+	}
+	err := &DecodeError{
+		Offset: start,
+		Bytes:  append([]byte(nil), d.data[start:end]...),
+		Reason: reason,
+	}
+	d.di, d.curByte, d.cbi = start, 0, 0
+	return err
+}
+
+// read a portion of the current byte
+func (d *disassembler) read(length int) byte {
+	if d.cbi == 8 {
+		d.curByte = d.next()
+		d.cbi = 0
+	}
+	bd := (d.curByte >> (8 - (d.cbi + length))) & mask(length)
+	d.cbi += length
+	return bd
+}
+
+// next returns the next byte in the input, or 0 and marks the
+// disassembler as truncated if there is no more input.
+func (d *disassembler) next() byte {
+	if d.di >= len(d.data) {
+		d.truncated = true
+		d.curByte = 0
+		d.cbi = 0
+		return 0
+	}
+	b := d.data[d.di]
+	d.di++
+	d.curByte = b
+	d.cbi = 0
+	return b
+}
+
+// read an 8-bit immediate
+func (d *disassembler) imm8() uint16 {
+	return uint16(d.next())
+}
+
+// signed 8-bit immediate
+func (d *disassembler) signedImm8() int8 {
+	return int8(d.next())
+}
+
+// read full 16-bit immediate
+func (d *disassembler) imm16() uint16 {
+	return d.imm8() | (d.imm8() << 8)
+}
+
+func (d *disassembler) parse(enc *Encoding) (Instruction, bool) {
+	di, curByte, cbi := d.di, d.curByte, d.cbi
+	rollback := func() {
+		d.di, d.curByte, d.cbi = di, curByte, cbi
+	}
+
+	in := Instruction{
+		Name:     enc.Name,
+		Type:     enc.Type,
+		Opcode:   enc.Opcode.Opcode,
+		W:        1,
+		Encoding: enc,
+	}
+
+	for _, b := range enc.Bytes {
+		for _, p := range b {
+			switch pname := p.Name; pname {
+			case "S":
+				in.S = d.read(p.Len)
+			case "D":
+				in.D = d.read(p.Len)
+			case "W":
+				in.W = d.read(p.Len)
+			case "V":
+				in.V = d.read(p.Len)
+			case "Z":
+				in.Z = d.read(p.Len)
+			case "MOD":
+				in.Mod = d.read(p.Len)
+			case "REG":
+				in.Reg = d.read(p.Len)
+			case "RM":
+				in.RM = d.read(p.Len)
+				switch in.Mod {
+				case 0b00: // Memory mode, no displacement *
+					if in.RM == 0b110 { // * special case
+						in.Displacement16 = int16(d.imm16())
+					}
+				case 0b01: // Memory mode, 8-bit displacement
+					// If the displacement it 1 byte, then if needs to be sign-extended to 16-bit.
+					in.Displacement8 = d.signedImm8()
+				case 0b10: // Memory mode, 16-bit displacement
+					in.Displacement16 = int16(d.imm16())
+				}
+			case "SR":
+				in.SR = d.read(p.Len)
+			case "DATAW":
+				switch {
+				case in.W > 0 && in.S == 0:
+					in.Data = d.imm16()
+				case in.W > 0:
+					// Sign extended to the word being operated on
+					in.Data = uint16(int16(d.signedImm8()))
+				default:
+					in.Data = d.imm8()
+				}
+			case "DATA":
+				in.Data = d.imm8()
+			case "JUMP":
+				in.JumpTarget = int16(d.signedImm8())
+			case "JUMPW":
+				in.JumpTarget = int16(d.imm16())
+			case "FAR":
+				in.Data = d.imm16()
+				in.Segment = d.imm16()
+			case "ADDR":
+				// The address is always 16-bit, W is the size of the data at it
+				in.Data = d.imm16()
+			case "DISP":
+				// Ignore
+			default:
+				if !p.IsConst {
+					panic("p is not a constant")
+				}
+
+				// Constant
+				c := d.read(p.Len)
+
+				// Constant not correct, likely not the correct instruction
+				if c != p.Const {
+					// fmt.Printf("error: found constant %v that didn't match %0b\n", p, c)
+					// Rollback to the original index in the data if we don't find a matching
+					// instruction
+					rollback()
+					return Instruction{}, false
+				}
+			}
+		}
+	}
+	if d.truncated {
+		rollback()
+		return Instruction{}, false
+	}
+	// A far pointer can't be loaded from a register
+	if enc.Type == "FARRM" && in.Mod == 0b11 {
+		rollback()
+		return Instruction{}, false
+	}
+	return in, true
+}
+
+type InstructionFlags int
+
+const (
+	FlagRepeat  InstructionFlags = 1 << iota // repne/repnz (F2)
+	FlagRepeatZ                              // rep/repe/repz (F3)
+	FlagLock
+	FlagESOverride
+	FlagCSOverride
+	FlagSSOverride
+	FlagDSOverride
+)
+
+type Instruction struct {
+	Name           string
+	Type           string
+	Opcode         byte
+	D              byte
+	W              byte
+	S              byte
+	V              byte
+	Z              byte
+	Mod            byte
+	Reg            byte
+	RM             byte
+	SR             byte
+	Data           uint16
+	Segment        uint16 // Segment of a direct intersegment call/jmp, Data is the offset
+	Displacement8  int8
+	Displacement16 int16
+	JumpTarget     int16 // Signed increment to the instruction pointer
+	Flags          InstructionFlags
+	Offset         int // Offset of this instruction in the input
+	Length         int // Length of this instruction in bytes
+	Encoding       *Encoding
+}
+
+// Prefixes returns the names of the instruction's prefixes, in the order
+// that nasm would print them. The repeat prefix is named as Format writes it,
+// repe rather than rep for the string instructions that compare.
+func (i Instruction) Prefixes() []string {
+	var names []string
+	for _, p := range []struct {
+		flag InstructionFlags
+		name string
+	}{
+		{FlagESOverride, "es"},
+		{FlagCSOverride, "cs"},
+		{FlagSSOverride, "ss"},
+		{FlagDSOverride, "ds"},
+		{FlagRepeat, i.repeatPrefix()},
+		{FlagRepeatZ, i.repeatPrefix()},
+		{FlagLock, "lock"},
+	} {
+		if i.Flags&p.flag != 0 {
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
+
+func (i Instruction) FlagSet(f InstructionFlags) bool {
+	return i.Flags&f == f
+}
+
+// Operands returns the instruction's operands, destination first.
+func (i Instruction) Operands() []Operand {
+	var ops []Operand
+	for _, typ := range strings.Split(i.Type, "__") {
+		switch typ {
+		case "REG":
+			ops = append(ops, Operand{Kind: OperandRegister, Reg: register(i.Reg, i.W)})
+		case "RM":
+			ops = append(ops, i.operandRM())
+		case "IMM", "DATA":
+			ops = append(ops, Operand{Kind: OperandImmediate, Imm: i.Data})
+		case "JUMP":
+			ops = append(ops, Operand{Kind: OperandRelative, JumpTarget: i.JumpTarget})
+		case "NEAR":
+			ops = append(ops, Operand{Kind: OperandRelative, JumpTarget: i.JumpTarget, Near: true})
+		case "FAR":
+			ops = append(ops, Operand{Kind: OperandFarPointer, Imm: i.Data, Segment: i.Segment})
+		case "FARRM":
+			o := i.operandRM()
+			o.Far = true
+			ops = append(ops, o)
+		case "MEM":
+			ea := EffectiveAddress{Displacement: int16(i.Data), Segment: i.SegmentOverride()}
+			ops = append(ops, Operand{Kind: OperandMemory, EA: ea})
+		case "ACC":
+			ops = append(ops, Operand{Kind: OperandRegister, Reg: register(0b000, i.W)})
+		case "DX":
+			ops = append(ops, Operand{Kind: OperandRegister, Reg: DX})
+		case "SR":
+			ops = append(ops, Operand{Kind: OperandSegment, Reg: segmentRegister(i.SR)})
+		case "V":
+			if i.V == 0 {
+				ops = append(ops, Operand{Kind: OperandImmediate, Imm: 1, UnknownSize: true})
+			} else {
+				ops = append(ops, Operand{Kind: OperandRegister, Reg: CL, UnknownSize: true})
+			}
+		case "":
+			// Do nothing
+		default:
+			panic(fmt.Sprintf("type %s not implemented", typ))
+		}
+	}
+	if i.D > 0 {
+		ops[0], ops[1] = ops[1], ops[0]
+	}
+	return ops
+}
+
+// Target returns the offset that the relative operand o jumps to.
+func (i Instruction) Target(o Operand) int {
+	return i.Offset + i.Length + int(o.JumpTarget)
+}
+
+func (i Instruction) operandRM() Operand {
+	if i.Mod == 0b11 {
+		return Operand{Kind: OperandRegister, Reg: register(i.RM, i.W)}
+	}
+	ea := effectiveAddress(i.Mod, i.RM, i.Displacement8, i.Displacement16)
+	ea.Segment = i.SegmentOverride()
+	return Operand{Kind: OperandMemory, EA: ea}
+}
+
+// SegmentOverride returns the segment register from a prefix, or RegNone.
+func (i Instruction) SegmentOverride() Register {
+	switch {
+	case i.FlagSet(FlagESOverride):
+		return ES
+	case i.FlagSet(FlagCSOverride):
+		return CS
+	case i.FlagSet(FlagSSOverride):
+		return SS
+	case i.FlagSet(FlagDSOverride):
+		return DS
+	}
+	return RegNone
+}
+
+func (i Instruction) String() string {
+	return i.Format(nil)
+}
+
+// Format returns the instruction in nasm syntax, referring to relative targets
+// by name if they are in labels, which is keyed by offset.
+func (i Instruction) Format(labels map[int]string) string {
+	var sb strings.Builder
+
+	if rep := i.repeatPrefix(); rep != "" {
+		sb.WriteString(rep)
+		sb.WriteString(" ")
+	}
+	if i.FlagSet(FlagLock) {
+		sb.WriteString("lock ")
+	}
+
+	ops := i.Operands()
+
+	// A segment override applies to the memory operand, but string
+	// instructions and xlat address memory implicitly so nasm needs it written
+	// as a prefix.
+	if seg := i.SegmentOverride(); seg != RegNone {
+		hasMemory := false
+		for _, o := range ops {
+			hasMemory = hasMemory || o.Kind == OperandMemory
+		}
+		if !hasMemory {
+			sb.WriteString(seg.String())
+			sb.WriteString(" ")
+		}
+	}
+
+	sb.WriteString(i.Name)
+
+	knownSize := false
+	for _, o := range ops {
+		if o.Kind == OperandRegister && !o.UnknownSize {
+			knownSize = true
+		}
+	}
+
+	for oi, o := range ops {
+		if oi > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(" ")
+
+		switch o.Kind {
+		case OperandRegister, OperandSegment:
+			sb.WriteString(o.Reg.String())
+		case OperandMemory:
+			if o.Far {
+				sb.WriteString("far ")
+			} else if !knownSize {
+				if i.W > 0 {
+					sb.WriteString("word ")
+				} else {
+					sb.WriteString("byte ")
+				}
+			}
+			if o.EA.Segment != RegNone {
+				sb.WriteString(o.EA.Segment.String())
+				sb.WriteString(":")
+			}
+			sb.WriteString("[")
+			sb.WriteString(o.EA.String())
+			sb.WriteString("]")
+		case OperandImmediate:
+			sb.WriteString(fmt.Sprintf("%d", o.Imm))
+		case OperandRelative:
+			if o.Near {
+				sb.WriteString("near ")
+			}
+			// nasm's $ is the start of the instruction, but the jump is relative
+			// to the end of it
+			if label, ok := labels[i.Target(o)]; ok {
+				sb.WriteString(label)
+			} else {
+				sb.WriteString(fmt.Sprintf("$%+d", int(o.JumpTarget)+i.Length))
+			}
+		case OperandFarPointer:
+			sb.WriteString(fmt.Sprintf("%d:%d", o.Segment, o.Imm))
+		}
+	}
+	return sb.String()
+}
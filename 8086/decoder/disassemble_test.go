@@ -0,0 +1,209 @@
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		offset int
+		bytes  []byte
+		reason DecodeErrorReason
+	}{
+		{
+			name:   "unknown opcode",
+			data:   []byte{0b11010110},
+			bytes:  []byte{0b11010110},
+			reason: ReasonUnknownOpcode,
+		},
+		{
+			name:   "truncated displacement",
+			data:   []byte{0b10001011, 0b10000110, 0x10},
+			bytes:  []byte{0b10001011, 0b10000110, 0x10},
+			reason: ReasonTruncated,
+		},
+		{
+			name:   "truncated after prefix",
+			data:   []byte{0b11110000},
+			bytes:  []byte{0b11110000},
+			reason: ReasonTruncated,
+		},
+		{
+			name:   "constant mismatch",
+			data:   []byte{0b10001111, 0b00001000},
+			bytes:  []byte{0b10001111},
+			reason: ReasonConstantMismatch,
+		},
+		{
+			name:   "duplicate prefix",
+			data:   []byte{0b11110011, 0b11110011, 0b10100100},
+			bytes:  []byte{0b11110011, 0b11110011},
+			reason: ReasonDuplicatePrefix,
+		},
+		{
+			name:   "conflicting segment override",
+			data:   []byte{0b00100110, 0b00101110, 0b10001010, 0b00000111},
+			bytes:  []byte{0b00100110, 0b00101110},
+			reason: ReasonConflictingPrefix,
+		},
+		{
+			name:   "conflicting repeat",
+			data:   []byte{0b11110010, 0b11110011, 0b10100110},
+			bytes:  []byte{0b11110010, 0b11110011},
+			reason: ReasonConflictingPrefix,
+		},
+		{
+			name:   "lock without memory destination",
+			data:   []byte{0b11110000, 0b00000001, 0b11011000},
+			bytes:  []byte{0b11110000, 0b00000001, 0b11011000},
+			reason: ReasonInvalidLock,
+		},
+		{
+			name:   "offset after valid instruction",
+			data:   []byte{0b10001001, 0b11011110, 0b11010110},
+			offset: 2,
+			bytes:  []byte{0b11010110},
+			reason: ReasonUnknownOpcode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &disassembler{data: tt.data}
+			var err error
+			for d.di < len(d.data) && err == nil {
+				_, err = d.nextInstruction()
+			}
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("expected *DecodeError, got %v", err)
+			}
+			if de.Offset != tt.offset {
+				t.Errorf("offset = %d, want %d", de.Offset, tt.offset)
+			}
+			if !bytes.Equal(de.Bytes, tt.bytes) {
+				t.Errorf("bytes = % x, want % x", de.Bytes, tt.bytes)
+			}
+			if de.Reason != tt.reason {
+				t.Errorf("reason = %v, want %v", de.Reason, tt.reason)
+			}
+			if d.di != tt.offset {
+				t.Errorf("di = %d, want rewind to %d", d.di, tt.offset)
+			}
+		})
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{0b11110011, 0b10100100}, "rep movsb"},
+		{[]byte{0b11110011, 0b10100111}, "repe cmpsw"},
+		{[]byte{0b11110010, 0b10101110}, "repne scasb"},
+		{[]byte{0b11110011, 0b10101010}, "rep stosb"},
+		{[]byte{0b00100110, 0b10100100}, "es movsb"},
+		{[]byte{0b11110011, 0b00101110, 0b10101101}, "rep cs lodsw"},
+		{[]byte{0b00101110, 0b11010111}, "cs xlat"},
+		{[]byte{0b00110110, 0b10001010, 0b00000111}, "mov al, ss:[bx]"},
+		{[]byte{0b11110000, 0b00111110, 0b11110110, 0b00010111}, "lock not byte ds:[bx]"},
+		{[]byte{0b11110000, 0b10000110, 0b00000111}, "lock xchg al, [bx]"},
+	}
+	for _, tt := range tests {
+		d := &disassembler{data: tt.data}
+		in, err := d.nextInstruction()
+		if err != nil {
+			t.Errorf("% x: %v", tt.data, err)
+			continue
+		}
+		if got := in.String(); got != tt.want {
+			t.Errorf("% x: got %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+// TestGeneratedDecoder checks that the decoder generated by cmd/codegen agrees
+// with disassembler on every first byte and MOD/REG/RM byte, with and without
+// prefixes, and on every truncation of each.
+func TestGeneratedDecoder(t *testing.T) {
+	r := rand.New(rand.NewSource(8086))
+	seen := map[string]bool{}
+
+	check := func(data []byte) {
+		t.Helper()
+		d := &disassembler{data: data}
+		want, wantErr := d.nextInstruction()
+		g := &genDecoder{data: data}
+		got, gotErr := g.nextInstruction()
+		if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(gotErr, wantErr) {
+			t.Fatalf("% x: generated decoder returned\n%#v, %v\nwant\n%#v, %v", data, got, gotErr, want, wantErr)
+		}
+		if d.di != g.di {
+			t.Fatalf("% x: generated decoder at %d, want %d", data, g.di, d.di)
+		}
+		if wantErr == nil {
+			seen[want.Name+" "+want.Type] = true
+		}
+	}
+
+	for _, prefix := range [][]byte{nil, {0b11110011}, {0b00101110, 0b11110000}} {
+		for b1 := 0; b1 < 256; b1++ {
+			for b2 := 0; b2 < 256; b2++ {
+				data := append([]byte(nil), prefix...)
+				data = append(data, byte(b1), byte(b2))
+				tail := make([]byte, 4)
+				r.Read(tail)
+				data = append(data, tail...)
+				for n := 0; n <= len(data); n++ {
+					check(data[:n])
+				}
+			}
+		}
+	}
+
+	for _, enc := range encoder.encodings {
+		if !seen[enc.Name+" "+enc.Type] {
+			t.Errorf("%q was never decoded", enc.Orig)
+		}
+	}
+}
+
+func TestImmediates(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+		n    int
+	}{
+		// An 8-bit immediate with S and W set is sign extended to a word
+		{[]byte{0x83, 0xc0, 0xff}, "add ax, 65535", 3},
+		{[]byte{0x83, 0xc0, 0x7f}, "add ax, 127", 3},
+		{[]byte{0x83, 0x06, 0x00, 0x10, 0x80}, "add word [4096], 65408", 5},
+		{[]byte{0x80, 0xc0, 0xff}, "add al, 255", 3},
+		{[]byte{0x81, 0xc0, 0xff, 0x00}, "add ax, 255", 4},
+		// The address of a mov to or from the accumulator is always 16 bits,
+		// W is the size of the data at it
+		{[]byte{0xa0, 0x34, 0x12}, "mov al, [4660]", 3},
+		{[]byte{0xa1, 0x34, 0x12}, "mov ax, [4660]", 3},
+		{[]byte{0xa2, 0x34, 0x12}, "mov [4660], al", 3},
+	}
+	for _, tt := range tests {
+		for _, d := range []interface {
+			nextInstruction() (Instruction, error)
+		}{&disassembler{data: tt.data}, &genDecoder{data: tt.data}} {
+			in, err := d.nextInstruction()
+			if err != nil {
+				t.Errorf("%T % x: %v", d, tt.data, err)
+				continue
+			}
+			if got := in.String(); got != tt.want || in.Length != tt.n {
+				t.Errorf("%T % x: got %q of %d bytes, want %q of %d", d, tt.data, got, in.Length, tt.want, tt.n)
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package decoder
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	_ "embed"
+)
+
+var (
+	//go:embed instruction_encodings.txt
+	instructionEncodings string
+
+	encoder = NewEncoder(instructionEncodings)
+)
+
+var sizes = map[string]int{
+	"D":     1,
+	"W":     1,
+	"S":     1,
+	"V":     1,
+	"Z":     1,
+	"MOD":   2,
+	"SR":    2,
+	"REG":   3,
+	"RM":    3,
+	"DISP":  8,
+	"DATA":  8,
+	"DATAW": 8,
+	"ADDR":  8,
+	"JUMP":  8,
+	"JUMPW": 8,
+	"FAR":   8,
+}
+
+func sizeOf(val string) int {
+	if size, ok := sizes[val]; ok {
+		return size
+	} else {
+		return len(val)
+	}
+}
+
+func nameOf(val string) string {
+	if _, ok := sizes[val]; ok {
+		return val
+	}
+	if !isNum(val) {
+		panic(fmt.Sprintf("%q is not a constant", val))
+	}
+	return "const_" + val
+}
+
+func isConst(val string) bool {
+	if _, ok := sizes[val]; !ok {
+		return true
+	}
+	return false
+}
+
+func isLikelyType(val string) bool {
+	return !isNum(val)
+}
+
+func isNum(val string) bool {
+	if val == "" {
+		return false
+	}
+	switch val[0] {
+	case '0', '1':
+		return true
+	}
+	return false
+}
+
+type Encoder struct {
+	rawEncoding string
+
+	encodings []Encoding
+
+	// table holds the encodings that match each possible first byte, in the
+	// order they should be tried.
+	table [256][]*Encoding
+}
+
+type Encoding struct {
+	Orig string
+	Line int // Line number in the table
+
+	Name   string
+	Type   string
+	Opcode Opcode
+
+	Bytes [][]Part
+}
+
+type Part struct {
+	Name  string
+	Start int
+	Len   int
+
+	IsConst bool
+	Const   byte
+}
+
+type Opcode struct {
+	Opcode byte
+	Len    int
+}
+
+func (o Opcode) String() string {
+	return fmt.Sprintf("%d: %0b", o.Len, o.Opcode)
+}
+
+func NewEncoder(instructionEncodings string) *Encoder {
+	e := &Encoder{rawEncoding: instructionEncodings}
+	for i, line := range strings.Split(instructionEncodings, "\n") {
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		enc, err := parseEncoding(line)
+		if err != nil {
+			log.Fatalf("line %d (%s): %v", i+1, line, err)
+		}
+		enc.Line = i + 1
+		e.encodings = append(e.encodings, enc)
+	}
+	e.buildTable()
+	return e
+}
+
+// buildTable fills in the table of encodings for each first byte.
+func (e *Encoder) buildTable() {
+	for b := range e.table {
+		e.table[b] = e.scan(byte(b))
+	}
+}
+
+// parseEncoding parses a line of instruction_encodings.txt: the name, an
+// optional type, then the bits of each byte with the fields separated by _.
+func parseEncoding(line string) (Encoding, error) {
+	enc := Encoding{Orig: line}
+
+	encoding := strings.Fields(line)
+	if len(encoding) == 0 {
+		return enc, fmt.Errorf("no name")
+	}
+	enc.Name = encoding[0]
+	start := 1
+	if len(encoding) > 1 && isLikelyType(encoding[1]) {
+		enc.Type = encoding[1]
+		start = 2
+	}
+	for _, typ := range strings.Split(enc.Type, "__") {
+		if !operandTypes[typ] {
+			return enc, fmt.Errorf("unknown operand type %q", typ)
+		}
+	}
+	for _, part := range encoding[start:] {
+		var parts []Part
+		total := 8
+
+		pi := 0
+		for _, p := range strings.Split(part, "_") {
+			if p == "" {
+				return enc, fmt.Errorf("empty field in %q", part)
+			}
+			if _, ok := sizes[p]; !ok && strings.Trim(p, "01") != "" {
+				return enc, fmt.Errorf("unknown field %q", p)
+			}
+			if enc.Opcode.Len == 0 {
+				enc.Opcode.Opcode = convert(p)
+				enc.Opcode.Len = len(p)
+			}
+			size := sizeOf(p)
+
+			part := Part{
+				Name:  nameOf(p),
+				Start: pi,
+				Len:   size,
+			}
+			if isConst(p) {
+				part.IsConst = true
+				part.Const = convert(p)
+			}
+			parts = append(parts, part)
+
+			total -= size
+			pi += size
+		}
+		if total != 0 {
+			return enc, fmt.Errorf("invalid part %q doesn't equal 8 bits", part)
+		}
+		enc.Bytes = append(enc.Bytes, parts)
+	}
+	if len(enc.Bytes) == 0 {
+		return enc, fmt.Errorf("no opcode")
+	}
+	return enc, nil
+}
+
+// operandTypes are the operand kinds that can make up an encoding's type.
+var operandTypes = map[string]bool{
+	"":      true,
+	"REG":   true,
+	"RM":    true,
+	"IMM":   true,
+	"DATA":  true,
+	"JUMP":  true,
+	"NEAR":  true,
+	"FAR":   true,
+	"FARRM": true,
+	"MEM":   true,
+	"ACC":   true,
+	"DX":    true,
+	"SR":    true,
+	"V":     true,
+}
+
+// Encodings returns every encoding in instruction_encodings.txt, in the order
+// they appear in the file.
+func Encodings() []*Encoding {
+	return encoder.Encodings()
+}
+
+// Encodings returns every encoding in e's table, in the order they appear in
+// it.
+func (e *Encoder) Encodings() []*Encoding {
+	encs := make([]*Encoding, len(e.encodings))
+	for i := range e.encodings {
+		encs[i] = &e.encodings[i]
+	}
+	return encs
+}
+
+// Decode returns the encodings that could start with b, longest opcode first.
+func (e *Encoder) Decode(b byte) []*Encoding {
+	return e.table[b]
+}
+
+// scan finds the encodings that could start with b by checking every encoding.
+// Longer opcodes are more specific so come first, then those that use the REG
+// field as an opcode extension, ordered by that extension.
+func (e *Encoder) scan(b byte) []*Encoding {
+	var found []*Encoding
+	for i := range e.encodings {
+		enc := &e.encodings[i]
+		if b>>(8-enc.Opcode.Len)&mask(enc.Opcode.Len) == enc.Opcode.Opcode {
+			found = append(found, enc)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Opcode.Len != found[j].Opcode.Len {
+			return found[i].Opcode.Len > found[j].Opcode.Len
+		}
+		ei, iok := found[i].regExtension()
+		ej, jok := found[j].regExtension()
+		if iok != jok {
+			return iok
+		}
+		return ei < ej
+	})
+	return found
+}
+
+// regExtension returns the constant in the REG position of the second byte,
+// which some encodings use as an extension of the opcode.
+func (e *Encoding) regExtension() (byte, bool) {
+	if len(e.Bytes) < 2 {
+		return 0, false
+	}
+	for _, p := range e.Bytes[1] {
+		if p.Start == 2 && p.Len == 3 && p.IsConst {
+			return p.Const, true
+		}
+	}
+	return 0, false
+}
+
+func mask(length int) byte {
+	m := byte(1)
+	for i := 1; i < length; i++ {
+		m = m << 1
+		m |= 1
+	}
+	return m
+}
+
+func convert(v string) byte {
+	b := byte(0)
+	for i, c := range v {
+		if c == '1' {
+			b |= 1 << (len(v) - 1 - i)
+		}
+	}
+	return b
+}
@@ -0,0 +1,85 @@
+package decoder
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomInstructions returns at least size bytes of randomly chosen, but
+// decodable, instructions.
+func randomInstructions(size int) []byte {
+	r := rand.New(rand.NewSource(8086))
+	data := make([]byte, 0, size+16)
+	buf := make([]byte, 16)
+	for len(data) < size {
+		r.Read(buf)
+		d := &disassembler{data: buf}
+		in, err := d.nextInstruction()
+		if err != nil {
+			continue
+		}
+		data = append(data, buf[:in.Length]...)
+	}
+	return data
+}
+
+// BenchmarkDecode decodes about 4 MB of random instructions with each
+// decoder. scan also runs the scan of every encoding that Encoder.Decode did
+// for each instruction before the dispatch table, to compare against it.
+func BenchmarkDecode(b *testing.B) {
+	data := randomInstructions(4 << 20)
+	for _, bb := range []struct {
+		name string
+		new  func() instructionDecoder
+		scan bool
+	}{
+		{"table", func() instructionDecoder { return &disassembler{data: data} }, false},
+		{"scan", func() instructionDecoder { return &disassembler{data: data} }, true},
+		{"generated", func() instructionDecoder { return &genDecoder{data: data} }, false},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for n := 0; n < b.N; n++ {
+				d := bb.new()
+				for off := 0; off < len(data); {
+					if bb.scan {
+						encoder.scan(data[off])
+					}
+					in, err := d.nextInstruction()
+					if err != nil {
+						b.Fatal(err)
+					}
+					off += in.Length
+				}
+			}
+		})
+	}
+}
+
+// instructionDecoder is implemented by disassembler and genDecoder.
+type instructionDecoder interface {
+	nextInstruction() (Instruction, error)
+}
+
+func BenchmarkEncoderScan(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		encoder.scan(byte(n))
+	}
+}
+
+func BenchmarkEncoderDecode(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		encoder.Decode(byte(n))
+	}
+}
+
+func TestEncoderTable(t *testing.T) {
+	for b := 0; b < 256; b++ {
+		encs := encoder.Decode(byte(b))
+		for i := 1; i < len(encs); i++ {
+			if encs[i-1].Opcode.Len < encs[i].Opcode.Len {
+				t.Errorf("%08b: %q tried before longer opcode %q", b, encs[i-1].Orig, encs[i].Orig)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package decoder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LintProblem is a mistake found in an encoding table by LintEncodings.
+type LintProblem struct {
+	Line int // 0 if the problem isn't with a single line
+	Msg  string
+}
+
+func (p LintProblem) String() string {
+	if p.Line == 0 {
+		return p.Msg
+	}
+	return fmt.Sprintf("%d: %s", p.Line, p.Msg)
+}
+
+// Table returns the embedded instruction_encodings.txt.
+func Table() string {
+	return instructionEncodings
+}
+
+// undefinedDirective starts a comment listing first bytes that don't start
+// any instruction, as hex bytes or ranges, e.g. "#undefined 60-6f d6".
+const undefinedDirective = "#undefined"
+
+// LintEncodings checks an encoding table in the format of
+// instruction_encodings.txt for mistakes that NewEncoder doesn't catch:
+//
+//   - lines that don't parse, e.g. unknown fields
+//   - a MOD_..._RM byte that isn't followed by DISP, or a DISP without one
+//   - the same mnemonic and type on more than one line
+//   - the same opcode bits under more than one mnemonic
+//   - encodings that can never be decoded, because an encoding tried before
+//     them in Encoder.Decode always matches, or because a combination of
+//     them does
+//   - first bytes that aren't a prefix, covered by an encoding or listed as
+//     undefined with #undefined
+func LintEncodings(table string) []LintProblem {
+	var problems []LintProblem
+	report := func(line int, format string, args ...interface{}) {
+		problems = append(problems, LintProblem{line, fmt.Sprintf(format, args...)})
+	}
+
+	e := &Encoder{rawEncoding: table}
+	undefined := map[int]int{} // first byte to line
+	seen := map[string]int{}   // name and type to line
+	bits := map[[4]byte]int{}  // constant bits to encoding
+	for i, line := range strings.Split(table, "\n") {
+		if strings.HasPrefix(line, undefinedDirective+" ") {
+			for _, f := range strings.Fields(line[len(undefinedDirective):]) {
+				lo, hi, err := parseByteRange(f)
+				if err != nil {
+					report(i+1, "%v", err)
+				}
+				for b := lo; b <= hi; b++ {
+					undefined[b] = i + 1
+				}
+			}
+			continue
+		}
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		enc, err := parseEncoding(line)
+		if err != nil {
+			report(i+1, "%v", err)
+			continue
+		}
+		enc.Line = i + 1
+
+		key := strings.TrimSpace(enc.Name + " " + enc.Type)
+		if prev, ok := seen[key]; ok {
+			report(enc.Line, "%s is also encoded on line %d", key, prev)
+		}
+		seen[key] = enc.Line
+
+		// The same opcode under another name is a typo that the decoder
+		// would resolve by picking whichever comes first
+		mask, value := enc.constantBits()
+		opcode := [4]byte{mask[0], mask[1], value[0], value[1]}
+		if prev, ok := bits[opcode]; ok && e.encodings[prev].Name != enc.Name {
+			prevEnc := &e.encodings[prev]
+			report(enc.Line, "%s has the same opcode bits as line %d (%s)", key, prevEnc.Line, prevEnc.Orig)
+		} else if !ok {
+			bits[opcode] = len(e.encodings)
+		}
+
+		modRM := false
+		for _, b := range enc.Bytes {
+			isDisp := len(b) == 1 && b[0].Name == "DISP"
+			switch {
+			case modRM && !isDisp:
+				report(enc.Line, "MOD_..._RM isn't followed by DISP")
+			case !modRM && isDisp:
+				report(enc.Line, "DISP doesn't follow a MOD_..._RM byte")
+			}
+			modRM = hasPart(b, "MOD") && hasPart(b, "RM")
+		}
+		if modRM {
+			report(enc.Line, "MOD_..._RM isn't followed by DISP")
+		}
+
+		e.encodings = append(e.encodings, enc)
+	}
+	e.buildTable()
+
+	problems = append(problems, e.lintReachable()...)
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	for b := 0; b < 256; b++ {
+		covered := len(e.table[b]) > 0 || prefix(byte(b)) != 0
+		line, isUndefined := undefined[b]
+		switch {
+		case covered && isUndefined:
+			report(line, "0x%02x is listed as undefined but starts an instruction", b)
+		case !covered && !isUndefined:
+			report(0, "0x%02x isn't a prefix, the first byte of an encoding or listed as undefined", b)
+		}
+	}
+	return problems
+}
+
+// lintReachable reports encodings that can't be decoded, by decoding every
+// combination of the first two bytes, which is where all the constants are.
+func (e *Encoder) lintReachable() []LintProblem {
+	reached := make([]bool, len(e.encodings))
+	index := map[*Encoding]int{}
+	for i := range e.encodings {
+		index[&e.encodings[i]] = i
+	}
+	for b1 := 0; b1 < 256; b1++ {
+		for b2 := 0; b2 < 256; b2++ {
+			data := []byte{byte(b1), byte(b2), 0, 0, 0, 0}
+			for _, enc := range e.table[b1] {
+				d := &disassembler{data: data}
+				d.next()
+				if _, ok := d.parse(enc); ok {
+					reached[index[enc]] = true
+					break
+				}
+			}
+		}
+	}
+
+	var problems []LintProblem
+	for i := range e.encodings {
+		if reached[i] {
+			continue
+		}
+		enc := &e.encodings[i]
+		msg := "can never be decoded"
+		if by := e.shadowedBy(enc); by != nil {
+			msg = fmt.Sprintf("is shadowed by line %d (%s)", by.Line, by.Orig)
+		}
+		problems = append(problems, LintProblem{enc.Line, fmt.Sprintf("%s %s", enc.Orig, msg)})
+	}
+	return problems
+}
+
+// shadowedBy returns an encoding that is tried before enc and matches
+// whatever enc does, or nil if there isn't a single one.
+func (e *Encoder) shadowedBy(enc *Encoding) *Encoding {
+	for _, other := range e.table[enc.Opcode.Opcode<<(8-enc.Opcode.Len)] {
+		if other == enc {
+			return nil
+		}
+		if other.covers(enc) {
+			return other
+		}
+	}
+	return nil
+}
+
+// covers reports whether every instruction matching o's constant bits also
+// matches e's.
+func (e *Encoding) covers(o *Encoding) bool {
+	if e.Type == "FARRM" && o.Type != "FARRM" {
+		// FARRM also needs MOD to be a memory mode
+		return false
+	}
+	em, ev := e.constantBits()
+	om, ov := o.constantBits()
+	for i := range em {
+		if em[i]&om[i] != em[i] || ev[i] != ov[i]&em[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// constantBits returns the mask and value of the constant bits in the first
+// two bytes, up to the first byte that isn't made of fields.
+func (e *Encoding) constantBits() (mask, value [2]byte) {
+	for i, parts := range e.Bytes {
+		if i == len(mask) || (len(parts) == 1 && !parts[0].IsConst && parts[0].Len == 8) {
+			break
+		}
+		for _, p := range parts {
+			if p.IsConst {
+				shift := 8 - p.Start - p.Len
+				mask[i] |= (1<<p.Len - 1) << shift
+				value[i] |= p.Const << shift
+			}
+		}
+	}
+	return mask, value
+}
+
+func hasPart(parts []Part, name string) bool {
+	for _, p := range parts {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseByteRange parses a hex byte, or a range of them like 60-6f.
+func parseByteRange(s string) (int, int, error) {
+	los, his, isRange := strings.Cut(s, "-")
+	lo, err := strconv.ParseUint(los, 16, 8)
+	if err != nil {
+		return 0, -1, fmt.Errorf("invalid byte %q", los)
+	}
+	if !isRange {
+		return int(lo), int(lo), nil
+	}
+	hi, err := strconv.ParseUint(his, 16, 8)
+	if err != nil || hi < lo {
+		return 0, -1, fmt.Errorf("invalid byte range %q", s)
+	}
+	return int(lo), int(hi), nil
+}
@@ -0,0 +1,86 @@
+package decoder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintEncodings(t *testing.T) {
+	for _, p := range LintEncodings(Table()) {
+		t.Errorf("instruction_encodings.txt:%s", p)
+	}
+}
+
+func TestLintEncodingsProblems(t *testing.T) {
+	for _, tc := range []struct {
+		table string
+		want  []string
+	}{
+		{
+			"je JUMP 01110100 JUMP\nje JUMP 01111111 JUMP\n",
+			[]string{"2: je JUMP is also encoded on line 1"},
+		},
+		{
+			"mov RM__REG 100010_D_W MOD_REG_RM DISP\nmov REG__RM 100010_D_W MOD_REG_RM DISP\n",
+			[]string{"2: mov REG__RM 100010_D_W MOD_REG_RM DISP is shadowed by line 1 (mov RM__REG 100010_D_W MOD_REG_RM DISP)"},
+		},
+		{
+			"a 00000000\nb 00000001\nc 0000000_W\n",
+			[]string{"3: c 0000000_W can never be decoded"},
+		},
+		{
+			"inc RM 1111111_W MOD_000_RM DISP\ndec RM 1111111_W MOD_000_RM DISP\n",
+			[]string{
+				"2: dec RM has the same opcode bits as line 1 (inc RM 1111111_W MOD_000_RM DISP)",
+				"2: dec RM 1111111_W MOD_000_RM DISP is shadowed by line 1 (inc RM 1111111_W MOD_000_RM DISP)",
+			},
+		},
+		{
+			"inc RM 1111111_W MOD_000_RM\n",
+			[]string{"1: MOD_..._RM isn't followed by DISP"},
+		},
+		{
+			"aam 11010100 00001010 DISP\n",
+			[]string{"1: DISP doesn't follow a MOD_..._RM byte"},
+		},
+		{
+			"mov RM__REG 100010_D_X MOD_REG_RM DISP\nmov FOO 10001000\n",
+			[]string{`1: unknown field "X"`, `2: unknown operand type "FOO"`},
+		},
+		{
+			"mov  10001000\nnop\t10010000\n",
+			nil,
+		},
+		{
+			"mov 100010__W\n   \nnop 10010000_\n",
+			[]string{`1: empty field in "100010__W"`, "2: no name", `3: empty field in "10010000_"`},
+		},
+		{
+			"nop 1001000\n",
+			[]string{`1: invalid part "1001000" doesn't equal 8 bits`},
+		},
+		{
+			"#undefined 60-6f zz\n#undefined 90\nnop 10010000\n",
+			[]string{`1: invalid byte "zz"`, "2: 0x90 is listed as undefined but starts an instruction"},
+		},
+	} {
+		// Ignore the first bytes that aren't covered
+		var got []string
+		for _, p := range LintEncodings(tc.table) {
+			if p.Line != 0 {
+				got = append(got, p.String())
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tc.table, strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+	}
+}
+
+func TestLintEncodingsCoverage(t *testing.T) {
+	// Everything but the prefixes, nop and f1
+	problems := LintEncodings("#undefined 00-25 27-2d 2f-35 37-3d 3f-8f 91-ef f4-ff\nnop 10010000\n")
+	if len(problems) != 1 || problems[0].String() != "0xf1 isn't a prefix, the first byte of an encoding or listed as undefined" {
+		t.Errorf("got %v, want only 0xf1 to be reported", problems)
+	}
+}
@@ -0,0 +1,266 @@
+package decoder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Register is one of the 8086's general purpose or segment registers, or one
+// of the byte halves of a general purpose register.
+type Register int
+
+const (
+	RegNone Register = iota
+
+	AL
+	CL
+	DL
+	BL
+	AH
+	CH
+	DH
+	BH
+
+	AX
+	CX
+	DX
+	BX
+	SP
+	BP
+	SI
+	DI
+
+	ES
+	CS
+	SS
+	DS
+)
+
+var registerNames = [...]string{
+	RegNone: "",
+	AL:      "al",
+	CL:      "cl",
+	DL:      "dl",
+	BL:      "bl",
+	AH:      "ah",
+	CH:      "ch",
+	DH:      "dh",
+	BH:      "bh",
+	AX:      "ax",
+	CX:      "cx",
+	DX:      "dx",
+	BX:      "bx",
+	SP:      "sp",
+	BP:      "bp",
+	SI:      "si",
+	DI:      "di",
+	ES:      "es",
+	CS:      "cs",
+	SS:      "ss",
+	DS:      "ds",
+}
+
+func (r Register) String() string {
+	if r < 0 || int(r) >= len(registerNames) {
+		return fmt.Sprintf("Register(%d)", int(r))
+	}
+	return registerNames[r]
+}
+
+// ParseRegister returns the register called name, or RegNone.
+func ParseRegister(name string) Register {
+	name = strings.ToLower(name)
+	for r, n := range registerNames {
+		if n != "" && n == name {
+			return Register(r)
+		}
+	}
+	return RegNone
+}
+
+// Width returns the size of the register in bytes.
+func (r Register) Width() int {
+	if r >= AL && r <= BH {
+		return 1
+	}
+	return 2
+}
+
+// Index returns the 16-bit register that r is part of, numbered as in the REG
+// field for general purpose registers (ax=0 ... di=7) followed by the segment
+// registers (es=8 ... ds=11).
+func (r Register) Index() int {
+	switch {
+	case r >= AL && r <= BL:
+		return int(r - AL)
+	case r >= AH && r <= BH:
+		return int(r - AH)
+	case r >= AX:
+		return int(r - AX)
+	}
+	return -1
+}
+
+// High reports whether r is the high byte of its 16-bit register.
+func (r Register) High() bool {
+	return r >= AH && r <= BH
+}
+
+// IsSegment reports whether r is a segment register.
+func (r Register) IsSegment() bool {
+	return r >= ES && r <= DS
+}
+
+// register returns the register encoded by a REG or RM field.
+func register(reg, w byte) Register {
+	if w == 0 {
+		return AL + Register(reg&0b111)
+	}
+	return AX + Register(reg&0b111)
+}
+
+// segmentRegister returns the register encoded by an SR field.
+func segmentRegister(sr byte) Register {
+	return ES + Register(sr&0b11)
+}
+
+// OperandKind is the kind of value an Operand refers to.
+type OperandKind int
+
+const (
+	OperandNone OperandKind = iota
+	OperandRegister
+	OperandMemory
+	OperandImmediate
+	OperandRelative
+	OperandSegment
+	OperandFarPointer
+)
+
+var operandKindNames = [...]string{
+	OperandNone:       "none",
+	OperandRegister:   "register",
+	OperandMemory:     "memory",
+	OperandImmediate:  "immediate",
+	OperandRelative:   "relative",
+	OperandSegment:    "segment",
+	OperandFarPointer: "far_pointer",
+}
+
+func (k OperandKind) String() string {
+	if k < 0 || int(k) >= len(operandKindNames) {
+		return fmt.Sprintf("OperandKind(%d)", int(k))
+	}
+	return operandKindNames[k]
+}
+
+// EffectiveAddress is a memory address computed from up to two registers and
+// a displacement, e.g. [bp + si - 4]. With no registers it's a direct address.
+type EffectiveAddress struct {
+	Base         Register // bx or bp
+	Index        Register // si or di
+	Displacement int16
+	Segment      Register // Segment override, or RegNone for the default segment
+}
+
+// effectiveAddress returns the effective address encoded by the MOD and RM
+// fields, with mod != 0b11.
+func effectiveAddress(mod, rm byte, disp8 int8, disp16 int16) EffectiveAddress {
+	if mod == 0b00 && rm == 0b110 {
+		return EffectiveAddress{Displacement: disp16}
+	}
+
+	var ea EffectiveAddress
+	switch rm {
+	case 0b000:
+		ea.Base, ea.Index = BX, SI
+	case 0b001:
+		ea.Base, ea.Index = BX, DI
+	case 0b010:
+		ea.Base, ea.Index = BP, SI
+	case 0b011:
+		ea.Base, ea.Index = BP, DI
+	case 0b100:
+		ea.Index = SI
+	case 0b101:
+		ea.Index = DI
+	case 0b110:
+		ea.Base = BP
+	case 0b111:
+		ea.Base = BX
+	}
+
+	switch mod {
+	case 0b01:
+		ea.Displacement = int16(disp8)
+	case 0b10:
+		ea.Displacement = disp16
+	}
+	return ea
+}
+
+// Direct reports whether the address is just a displacement.
+func (ea EffectiveAddress) Direct() bool {
+	return ea.Base == RegNone && ea.Index == RegNone
+}
+
+// DefaultSegment returns the segment the address is in, taking any override
+// into account; bp based addresses are in ss, everything else in ds.
+func (ea EffectiveAddress) DefaultSegment() Register {
+	switch {
+	case ea.Segment != RegNone:
+		return ea.Segment
+	case ea.Base == BP:
+		return SS
+	}
+	return DS
+}
+
+// String returns the address in nasm syntax, without the surrounding
+// brackets or segment override.
+func (ea EffectiveAddress) String() string {
+	var sb strings.Builder
+	if ea.Base != RegNone {
+		sb.WriteString(ea.Base.String())
+	}
+	if ea.Index != RegNone {
+		if ea.Base != RegNone {
+			sb.WriteString(" + ")
+		}
+		sb.WriteString(ea.Index.String())
+	}
+	switch {
+	case ea.Direct():
+		// A direct address is unsigned
+		sb.WriteString(fmt.Sprintf("%d", uint16(ea.Displacement)))
+	case ea.Displacement > 0:
+		sb.WriteString(fmt.Sprintf(" + %d", ea.Displacement))
+	case ea.Displacement < 0:
+		sb.WriteString(fmt.Sprintf(" - %d", -int(ea.Displacement)))
+	}
+	return sb.String()
+}
+
+type Operand struct {
+	Kind OperandKind
+
+	// Reg is the register for OperandRegister and OperandSegment
+	Reg Register
+	// EA is the address for OperandMemory
+	EA EffectiveAddress
+	// Imm is the value for OperandImmediate, and the offset for
+	// OperandFarPointer
+	Imm uint16
+	// Segment is the segment for OperandFarPointer
+	Segment uint16
+	// JumpTarget is the signed increment to the instruction pointer, from the
+	// end of the instruction, for OperandRelative
+	JumpTarget int16
+
+	Near bool // 16-bit relative jump
+	Far  bool // Memory operand holding a segment:offset pointer
+
+	// UnknownSize is set for the shift count, which doesn't decide whether
+	// the instruction operates on a byte or a word
+	UnknownSize bool
+}
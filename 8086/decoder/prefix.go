@@ -0,0 +1,93 @@
+package decoder
+
+// prefix returns the flag for the prefix byte b, or 0 if b isn't a prefix.
+func prefix(b byte) InstructionFlags {
+	switch b {
+	case 0b11110010:
+		return FlagRepeat
+	case 0b11110011:
+		return FlagRepeatZ
+	case 0b00100110:
+		return FlagESOverride
+	case 0b00101110:
+		return FlagCSOverride
+	case 0b00110110:
+		return FlagSSOverride
+	case 0b00111110:
+		return FlagDSOverride
+	case 0b11110000:
+		return FlagLock
+	}
+	return 0
+}
+
+const (
+	flagsRepeat  = FlagRepeat | FlagRepeatZ
+	flagsSegment = FlagESOverride | FlagCSOverride | FlagSSOverride | FlagDSOverride
+)
+
+// addPrefix adds the prefix p to flags. It returns a non-zero reason if p has
+// already been seen, or it conflicts with another prefix of the same kind.
+func addPrefix(flags, p InstructionFlags) (InstructionFlags, DecodeErrorReason) {
+	switch {
+	case flags&p != 0:
+		return flags, ReasonDuplicatePrefix
+	case p&flagsRepeat != 0 && flags&flagsRepeat != 0,
+		p&flagsSegment != 0 && flags&flagsSegment != 0:
+		return flags, ReasonConflictingPrefix
+	}
+	return flags | p, 0
+}
+
+// lockable reports whether the instruction can be prefixed with lock, which
+// is only meaningful for a read-modify-write of memory.
+func (i Instruction) lockable() bool {
+	ops := i.Operands()
+	switch i.Name {
+	case "xchg":
+		for _, o := range ops {
+			if o.Kind == OperandMemory {
+				return true
+			}
+		}
+	case "add", "adc", "sub", "sbb", "and", "or", "xor", "not", "neg", "inc", "dec":
+		return len(ops) > 0 && ops[0].Kind == OperandMemory
+	}
+	return false
+}
+
+// stringInstructions are the instructions that repeat with a rep prefix. Those
+// that are true compare and stop on ZF.
+var stringInstructions = map[string]bool{
+	"movsb": false,
+	"movsw": false,
+	"lodsb": false,
+	"lodsw": false,
+	"stosb": false,
+	"stosw": false,
+	"cmpsb": true,
+	"cmpsw": true,
+	"scasb": true,
+	"scasw": true,
+}
+
+// IsString reports whether the instruction is a string instruction.
+func (i Instruction) IsString() bool {
+	_, ok := stringInstructions[i.Name]
+	return ok
+}
+
+// repeatPrefix returns the name of the repeat prefix, if any, as nasm would
+// write it for this instruction.
+func (i Instruction) repeatPrefix() string {
+	switch {
+	case i.FlagSet(FlagRepeat):
+		return "repne"
+	case i.FlagSet(FlagRepeatZ):
+		if stringInstructions[i.Name] {
+			return "repe"
+		}
+		return "rep"
+	}
+	return ""
+}
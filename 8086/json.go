@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/hex"
+
+	"8086/decoder"
+)
+
+// jsonInstruction is an instruction as written by -format=json and jsonl, and
+// in the blocks of 8086 cfg -format=json. The schema is documented in
+// schema/instruction.schema.json, keep them in sync.
+type jsonInstruction struct {
+	Offset   int           `json:"offset"`
+	Bytes    string        `json:"bytes"`
+	Length   int           `json:"length"`
+	Label    string        `json:"label,omitempty"`
+	Mnemonic string        `json:"mnemonic"`
+	Prefixes []string      `json:"prefixes"`
+	Operands []jsonOperand `json:"operands"`
+	Text     string        `json:"text"`
+	Encoding string        `json:"encoding,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type jsonOperand struct {
+	Kind string `json:"kind"`
+
+	Register string `json:"register,omitempty"`
+
+	// Memory
+	Size         int    `json:"size,omitempty"`
+	Base         string `json:"base,omitempty"`
+	Index        string `json:"index,omitempty"`
+	Displacement *int   `json:"displacement,omitempty"`
+	Segment      string `json:"segment,omitempty"`
+
+	// Immediate
+	Value *uint16 `json:"value,omitempty"`
+
+	// Relative
+	Target *int `json:"target,omitempty"`
+
+	// Far pointer
+	FarSegment *uint16 `json:"far_segment,omitempty"`
+	FarOffset  *uint16 `json:"far_offset,omitempty"`
+}
+
+func newJSONInstruction(in decoder.Instruction, b []byte, labels map[int]string) jsonInstruction {
+	ji := jsonInstruction{
+		Offset:   in.Offset,
+		Bytes:    hex.EncodeToString(b),
+		Length:   in.Length,
+		Label:    labels[in.Offset],
+		Mnemonic: in.Name,
+		Prefixes: in.Prefixes(),
+		Operands: []jsonOperand{},
+		Text:     in.Format(labels),
+	}
+	if ji.Prefixes == nil {
+		ji.Prefixes = []string{}
+	}
+	if in.Encoding != nil {
+		ji.Encoding = in.Encoding.Orig
+	}
+	for _, o := range in.Operands() {
+		ji.Operands = append(ji.Operands, newJSONOperand(in, o))
+	}
+	return ji
+}
+
+// newJSONError returns the record for a byte that couldn't be decoded and is
+// emitted as a db directive.
+func newJSONError(offset int, b byte, labels map[int]string, err error) jsonInstruction {
+	ji := newJSONData(offset, []byte{b}, labels)
+	ji.Error = err.Error()
+	return ji
+}
+
+// newJSONData returns a record for bytes that are printed as db.
+func newJSONData(offset int, b []byte, labels map[int]string) jsonInstruction {
+	return jsonInstruction{
+		Offset:   offset,
+		Bytes:    hex.EncodeToString(b),
+		Length:   len(b),
+		Label:    labels[offset],
+		Mnemonic: "db",
+		Prefixes: []string{},
+		Operands: []jsonOperand{},
+		Text:     dbDirective(b...),
+	}
+}
+
+func newJSONOperand(in decoder.Instruction, o decoder.Operand) jsonOperand {
+	jo := jsonOperand{Kind: o.Kind.String()}
+	switch o.Kind {
+	case decoder.OperandRegister, decoder.OperandSegment:
+		jo.Register = o.Reg.String()
+	case decoder.OperandMemory:
+		switch {
+		case o.Far, in.Name == "lds", in.Name == "les":
+			// lds and les load a segment:offset pointer too
+			jo.Size = 4
+		case in.W > 0:
+			jo.Size = 2
+		default:
+			jo.Size = 1
+		}
+		jo.Base = registerName(o.EA.Base)
+		jo.Index = registerName(o.EA.Index)
+		disp := int(o.EA.Displacement)
+		if o.EA.Direct() {
+			disp = int(uint16(o.EA.Displacement))
+		}
+		jo.Displacement = &disp
+		jo.Segment = registerName(o.EA.Segment)
+	case decoder.OperandImmediate:
+		jo.Value = &o.Imm
+	case decoder.OperandRelative:
+		target := in.Target(o)
+		jo.Target = &target
+	case decoder.OperandFarPointer:
+		jo.FarSegment = &o.Segment
+		jo.FarOffset = &o.Imm
+	}
+	return jo
+}
+
+func registerName(r decoder.Register) string {
+	if r == decoder.RegNone {
+		return ""
+	}
+	return r.String()
+}
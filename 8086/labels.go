@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"8086/decoder"
+)
+
+// collectLabels does a first pass over data and names every jump or call target
+// that starts a line of output. If dbOnError is set, undecodable bytes are
+// emitted as their own db lines and so can be labelled too.
+func collectLabels(data []byte, dbOnError bool) map[int]string {
+	lines := map[int]bool{}
+	var targets []int
+
+	it := decoder.NewIterator(data)
+	for !it.Done() {
+		start := it.Offset()
+		in, err := it.Next()
+		if err != nil {
+			if dbOnError {
+				lines[start] = true
+			}
+			it.Seek(start + 1)
+			continue
+		}
+		lines[start] = true
+		for _, o := range in.Operands() {
+			if o.Kind == decoder.OperandRelative {
+				targets = append(targets, in.Target(o))
+			}
+		}
+	}
+
+	return nameLabels(lines, targets)
+}
+
+// traceLabels names every jump or call target in the code found by
+// traceCode. Every target was followed, so is the start of an instruction
+// unless it overlapped another one.
+func traceLabels(code map[int]decoder.Instruction) map[int]string {
+	lines := map[int]bool{}
+	var targets []int
+	for off, in := range code {
+		lines[off] = true
+		if f := flowOf(in); f.target >= 0 {
+			targets = append(targets, f.target)
+		}
+	}
+	return nameLabels(lines, targets)
+}
+
+// nameLabels names the targets that start a line.
+func nameLabels(lines map[int]bool, targets []int) map[int]string {
+	labels := map[int]string{}
+	for _, t := range targets {
+		if lines[t] {
+			labels[t] = fmt.Sprintf("label_%04x", t)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"8086/decoder"
+)
+
+// lintEncodingsMain is the lint-encodings subcommand, which checks an
+// encoding table for mistakes, by default the one built in to the decoder.
+func lintEncodingsMain(args []string) int {
+	fs := flag.NewFlagSet("lint-encodings", flag.ContinueOnError)
+	input := fs.String("input", "", "encoding table to check, instead of the built in instruction_encodings.txt")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	name, table := "instruction_encodings.txt", decoder.Table()
+	if *input != "" {
+		b, err := os.ReadFile(*input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		name, table = *input, string(b)
+	}
+
+	problems := decoder.LintEncodings(table)
+	for _, p := range problems {
+		if p.Line == 0 {
+			fmt.Printf("%s: %s\n", name, p.Msg)
+		} else {
+			fmt.Printf("%s:%d: %s\n", name, p.Line, p.Msg)
+		}
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"8086/decoder"
+)
+
+const (
+	// listingBytes is the number of instruction bytes shown on a line, the
+	// rest go on continuation lines
+	listingBytes = 6
+	// listingTextWidth is the width of the instruction column
+	listingTextWidth = 32
+)
+
+// lister writes an assembler style listing: the segment:offset of each
+// instruction, its bytes in hex, the instruction and an optional comment,
+// split into pages with a header.
+type lister struct {
+	w     io.Writer
+	title string
+
+	// pageLength is the number of lines on a page, including the header. If
+	// zero the listing isn't paginated.
+	pageLength int
+	segment    uint16
+	org        uint16
+
+	line int
+	page int
+}
+
+// address returns the segment:offset of offset in the input
+func (l *lister) address(offset int) string {
+	return fmt.Sprintf("%04X:%04X", l.segment, uint16(offset)+l.org)
+}
+
+func (l *lister) println(s string) {
+	if l.pageLength > 0 && l.line%l.pageLength == 0 {
+		if l.page > 0 {
+			fmt.Fprint(l.w, "\f")
+		}
+		l.page++
+		fmt.Fprintf(l.w, "%-60s Page %d\n\n", l.title, l.page)
+		l.line += 2
+	}
+	fmt.Fprintln(l.w, strings.TrimRight(s, " "))
+	l.line++
+}
+
+func (l *lister) label(name string) {
+	l.println(fmt.Sprintf("%-9s  %-*s  %s:", "", listingBytes*2, "", name))
+}
+
+func (l *lister) row(offset int, b []byte, text, comment string) {
+	n := len(b)
+	if n > listingBytes {
+		n = listingBytes
+	}
+	line := fmt.Sprintf("%s  %-*X      %-*s", l.address(offset), listingBytes*2, b[:n], listingTextWidth, text)
+	if comment != "" {
+		line += " ; " + comment
+	}
+	l.println(line)
+	for b = b[n:]; len(b) > 0; b = b[n:] {
+		n = len(b)
+		if n > listingBytes {
+			n = listingBytes
+		}
+		l.println(fmt.Sprintf("%-9s  %X", "", b[:n]))
+	}
+}
+
+// comment returns the comment for an instruction in the listing.
+func (l *lister) comment(in decoder.Instruction) string {
+	var comments []string
+	for _, o := range in.Operands() {
+		switch o.Kind {
+		case decoder.OperandRelative:
+			comments = append(comments, "-> "+l.address(in.Target(o)))
+		case decoder.OperandFarPointer:
+			comments = append(comments, fmt.Sprintf("-> %04X:%04X", o.Segment, o.Imm))
+		}
+	}
+	return strings.Join(comments, ", ")
+}
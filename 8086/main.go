@@ -1,16 +1,32 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+
+	"8086/decoder"
+	"8086/sim"
 )
 
 var (
 	inputFileFlag = flag.String("input", "", "8086 binary file to read")
 	debugFlag     = flag.Bool("debug", false, "debug output")
 	execFlag      = flag.Bool("exec", false, "execute instructions")
+	onErrorFlag   = flag.String("on-error", "stop", "what to do with undecodable bytes: stop, skip or db")
+	labelsFlag    = flag.Bool("labels", false, "print jump and call targets as labels")
+	formatFlag    = flag.String("format", "text", "output format: text, listing, json or jsonl")
+	pageFlag      = flag.Int("page-length", 60, "lines per page of -format=listing, 0 to not paginate")
+	segmentFlag   = flag.String("segment", "0", "segment the input is loaded at, for -format=listing and -exec")
+	orgFlag       = flag.String("org", "0", "offset the input is loaded at, for -format=listing and -exec")
+	recursiveFlag = flag.Bool("recursive", false, "only decode code reachable from offset 0 and -entry, printing the rest as db")
+	entryFlag     = flag.String("entry", "", "comma separated offsets of extra entry points for -recursive")
+	clocksFlag    = flag.Bool("clocks", false, "print the estimated clocks of each instruction and the running total")
+	cpuFlag       = flag.String("cpu", "8086", "cpu for -clocks: 8086 or 8088")
 )
 
 func main() {
@@ -18,44 +34,266 @@ func main() {
 }
 
 func main1() int {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "asm":
+			return asmMain(os.Args[2:])
+		case "cfg":
+			return cfgMain(os.Args[2:])
+		case "lint-encodings":
+			return lintEncodingsMain(os.Args[2:])
+		}
+	}
+
 	flag.Parse()
 
+	switch *onErrorFlag {
+	case "stop", "skip", "db":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -on-error %q: must be stop, skip or db\n", *onErrorFlag)
+		return 2
+	}
+	switch *formatFlag {
+	case "text":
+	case "listing", "json", "jsonl":
+		if *execFlag || *debugFlag {
+			fmt.Fprintf(os.Stderr, "-format=%s can't be used with -exec or -debug\n", *formatFlag)
+			return 2
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -format %q: must be text, listing, json or jsonl\n", *formatFlag)
+		return 2
+	}
+	if *cpuFlag != "8086" && *cpuFlag != "8088" {
+		fmt.Fprintf(os.Stderr, "invalid -cpu %q: must be 8086 or 8088\n", *cpuFlag)
+		return 2
+	}
+	if *recursiveFlag && *execFlag {
+		fmt.Fprintln(os.Stderr, "-recursive can't be used with -exec")
+		return 2
+	}
+	segment, err := strconv.ParseUint(*segmentFlag, 0, 16)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -segment: %v\n", err)
+		return 2
+	}
+	org, err := strconv.ParseUint(*orgFlag, 0, 16)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -org: %v\n", err)
+		return 2
+	}
+
 	data, err := os.ReadFile(*inputFileFlag)
 	if err != nil {
 		log.Fatal(err)
 	}
+	entries, err := parseEntries(*entryFlag, len(data))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -entry: %v\n", err)
+		return 2
+	}
+
+	// code is the reachable instructions with -recursive
+	var code map[int]decoder.Instruction
+	if *recursiveFlag {
+		code = traceCode(data, entries)
+	}
+
+	var labels map[int]string
+	switch {
+	case *labelsFlag && code != nil:
+		labels = traceLabels(code)
+	case *labelsFlag:
+		labels = collectLabels(data, *onErrorFlag == "db")
+	}
 
-	s := &simulator{}
+	var (
+		records []jsonInstruction
+		enc     = json.NewEncoder(os.Stdout)
+	)
+	emit := func(ji jsonInstruction) {
+		if *formatFlag == "jsonl" {
+			enc.Encode(ji)
+		} else {
+			records = append(records, ji)
+		}
+	}
 
-	d := &disassembler{data: data}
-	for d.di < len(data) {
-		start := d.di
-		in := d.nextInstruction()
-		fmt.Print(in)
+	lst := &lister{
+		w:          os.Stdout,
+		title:      *inputFileFlag,
+		pageLength: *pageFlag,
+		segment:    uint16(segment),
+		org:        uint16(org),
+	}
+
+	s := sim.New()
+	clocks := 0 // running total for -clocks
+
+	mem := data
+	if *execFlag {
+		// Run the program from memory at -segment:-org, where it can see its
+		// own writes
+		s.SetReg(decoder.CS, uint16(segment))
+		s.IP = uint16(org)
+		mem = s.Load(data)
+	}
+	it := decoder.NewIterator(mem)
+	for !it.Done() {
+		start := it.Offset()
+		if label, ok := labels[start]; ok {
+			switch *formatFlag {
+			case "text":
+				fmt.Printf("%s:\n", label)
+			case "listing":
+				lst.label(label)
+			}
+		}
+		if _, ok := code[start]; code != nil && !ok {
+			// Print the bytes up to the next instruction as data
+			end := dataEnd(code, start, len(data))
+			for off := start; off < end; off += dbLineBytes {
+				n := dbLineBytes
+				if end-off < n {
+					n = end - off
+				}
+				b := mem[off : off+n]
+				switch *formatFlag {
+				case "text":
+					fmt.Println(dbDirective(b...))
+				case "listing":
+					lst.row(off, b, dbDirective(b...), "")
+				default:
+					emit(newJSONData(off, b, labels))
+				}
+			}
+			it.Seek(end)
+			continue
+		}
 
-		// Simulate instructions
+		in, err := it.Next()
+		if err != nil {
+			switch *onErrorFlag {
+			case "skip":
+				fmt.Fprintf(os.Stderr, "%v, skipping\n", err)
+			case "db":
+				switch *formatFlag {
+				case "text":
+					fmt.Println(dbDirective(mem[start]))
+				case "listing":
+					lst.row(start, mem[start:start+1], dbDirective(mem[start]), err.(*decoder.DecodeError).Reason.String())
+				default:
+					emit(newJSONError(start, mem[start], labels, err))
+				}
+			default:
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			// Resync one byte forward, and step over the byte when running
+			// too so that ip still matches where decoding carries on
+			it.Seek(start + 1)
+			if *execFlag {
+				s.IP++
+			}
+			continue
+		}
+
+		switch *formatFlag {
+		case "listing":
+			comment := lst.comment(in)
+			if *clocksFlag {
+				if comment != "" {
+					comment += ", "
+				}
+				comment += clocksComment(in, clockParams(in, *cpuFlag == "8088"), &clocks)
+			}
+			lst.row(start, mem[start:start+in.Length], in.Format(labels), comment)
+			continue
+		case "json", "jsonl":
+			emit(newJSONInstruction(in, mem[start:start+in.Length], labels))
+			continue
+		}
+
+		if *debugFlag {
+			fmt.Printf("inst=%#v\n", in)
+			for _, o := range in.Operands() {
+				fmt.Printf("> op: %#v\n", o)
+			}
+		}
+		fmt.Print(in.Format(labels))
+
+		p := clockParams(in, *cpuFlag == "8088")
 		if *execFlag {
-			s.exec(d.di, in)
-			fmt.Printf(" ; ip=%d, flags=%v | ", s.ip, s.flags)
-			for _, r := range s.regs {
+			s.ClockParams(in, &p)
+			if err := s.Exec(in); err != nil {
+				fmt.Println()
+				fmt.Fprintf(os.Stderr, "%#x: %v\n", start, err)
+				return 1
+			}
+			p.Taken = s.Taken
+			if s.Repeats > 0 {
+				// cmps and scas can stop before cx runs out
+				p.Count = s.Repeats
+			}
+		}
+		if *clocksFlag {
+			fmt.Print(" ; " + clocksComment(in, p, &clocks))
+		}
+
+		// Print the simulator's state
+		if *execFlag {
+			if len(s.Stack) > 0 {
+				ops := make([]string, len(s.Stack))
+				for i, o := range s.Stack {
+					ops[i] = o.String()
+				}
+				fmt.Print(" ; " + strings.Join(ops, ", "))
+			}
+			fmt.Printf(" ; ip=%d, flags=%v | ", s.IP, s.Flags)
+			for _, r := range s.Regs {
 				fmt.Printf("0x%x ", r)
 			}
-			// TODO: find a better way to do this.
-			// If we are in exec mode, then the instruction pointer needs to be controlled
-			// via the simulator.
-			d.di = s.ip
 		}
 
 		// Print debug info
 		if *debugFlag {
 			fmt.Printf(" (")
-			for i := start; i < d.di; i++ {
-				fmt.Printf(" %08b", data[i])
+			for i := start; i < start+in.Length; i++ {
+				fmt.Printf(" %08b", mem[i])
 			}
 			fmt.Printf(" )")
 		}
 		fmt.Println()
+
+		if *execFlag {
+			// Carry on from cs:ip, until the program halts or jumps out of
+			// itself
+			if s.Halted || s.PC() < 0 {
+				break
+			}
+			it.Seek(s.PC())
+		}
+	}
+
+	if *formatFlag == "json" {
+		if records == nil {
+			records = []jsonInstruction{}
+		}
+		enc.SetIndent("", "  ")
+		enc.Encode(records)
 	}
 
 	return 0
 }
+
+// dbLineBytes is the number of bytes of data printed on each db line.
+const dbLineBytes = 8
+
+// dbDirective returns a nasm directive that emits the bytes b as they are.
+func dbDirective(b ...byte) string {
+	hex := make([]string, len(b))
+	for i, c := range b {
+		hex[i] = fmt.Sprintf("0x%02x", c)
+	}
+	return "db " + strings.Join(hex, ", ")
+}
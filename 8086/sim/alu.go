@@ -0,0 +1,295 @@
+package sim
+
+import (
+	"errors"
+	"math/bits"
+
+	"8086/decoder"
+)
+
+// The arithmetic and logic operations of the simulator. Each takes the width
+// of its operands in bytes, 1 or 2, and sets the flags the 8086 manual says
+// the instruction affects, leaving the others as they were.
+
+// arithFlags are the flags set by add and sub.
+const arithFlags = flagCF | flagPF | flagAF | flagZF | flagSF | flagOF
+
+// widthMask returns the mask of the bits in a value w bytes wide.
+func widthMask(w int) uint32 {
+	if w == 1 {
+		return 0xff
+	}
+	return 0xffff
+}
+
+// signBit returns the sign bit of a value w bytes wide.
+func signBit(w int) uint32 {
+	if w == 1 {
+		return 0x80
+	}
+	return 0x8000
+}
+
+// setSZP sets SF, ZF and PF from a result w bytes wide. PF only looks at the
+// low byte.
+func (s *Simulator) setSZP(w int, r uint32) {
+	s.Flags.setTo(flagSF, r&signBit(w) != 0)
+	s.Flags.setTo(flagZF, r&widthMask(w) == 0)
+	s.Flags.setTo(flagPF, bits.OnesCount8(uint8(r))%2 == 0)
+}
+
+// add returns a + b + carry, as add and adc.
+func (s *Simulator) add(w int, a, b uint16, carry bool) uint16 {
+	x, y := uint32(a), uint32(b)
+	r := x + y
+	if carry {
+		r++
+	}
+	s.Flags.setTo(flagCF, r > widthMask(w))
+	s.Flags.setTo(flagAF, (x^y^r)&0x10 != 0)
+	s.Flags.setTo(flagOF, (x^r)&(y^r)&signBit(w) != 0)
+	s.setSZP(w, r)
+	return uint16(r & widthMask(w))
+}
+
+// sub returns a - b - borrow, as sub, sbb and cmp.
+func (s *Simulator) sub(w int, a, b uint16, borrow bool) uint16 {
+	x, y := uint32(a), uint32(b)
+	if borrow {
+		y++
+	}
+	r := x - y
+	s.Flags.setTo(flagCF, y > x)
+	s.Flags.setTo(flagAF, (x^uint32(b)^r)&0x10 != 0)
+	s.Flags.setTo(flagOF, (x^uint32(b))&(x^r)&signBit(w) != 0)
+	s.setSZP(w, r)
+	return uint16(r & widthMask(w))
+}
+
+// inc returns a + 1, which doesn't change CF.
+func (s *Simulator) inc(w int, a uint16) uint16 {
+	cf := s.Flags.isSet(flagCF)
+	r := s.add(w, a, 1, false)
+	s.Flags.setTo(flagCF, cf)
+	return r
+}
+
+// dec returns a - 1, which doesn't change CF.
+func (s *Simulator) dec(w int, a uint16) uint16 {
+	cf := s.Flags.isSet(flagCF)
+	r := s.sub(w, a, 1, false)
+	s.Flags.setTo(flagCF, cf)
+	return r
+}
+
+// neg returns 0 - a. CF is set unless a is 0.
+func (s *Simulator) neg(w int, a uint16) uint16 {
+	return s.sub(w, 0, a, false)
+}
+
+// logic sets the flags for the result of and, or, xor and test, which clear
+// CF and OF. AF is undefined, and cleared.
+func (s *Simulator) logic(w int, r uint16) uint16 {
+	s.Flags &^= flagCF | flagOF | flagAF
+	s.setSZP(w, uint32(r))
+	return r
+}
+
+// shift returns a shifted or rotated count times by one of shl, shr, sar,
+// rol, ror, rcl or rcr. The 8086 doesn't mask the count, and works through it
+// one bit at a time, so CF is the last bit shifted out and OF is set from the
+// last step; it's only defined by the manual when the count is 1. Rotates
+// only change CF and OF. A count of 0 changes nothing.
+func (s *Simulator) shift(name string, w int, a uint16, count int) uint16 {
+	if count == 0 {
+		return a
+	}
+	mask, sign := widthMask(w), signBit(w)
+	r := uint32(a) & mask
+	cf := s.Flags.isSet(flagCF)
+	of := false
+	for i := 0; i < count; i++ {
+		switch name {
+		case "shl", "sal":
+			cf = r&sign != 0
+			r = (r << 1) & mask
+			of = (r&sign != 0) != cf
+		case "shr":
+			of = r&sign != 0
+			cf = r&1 != 0
+			r >>= 1
+		case "sar":
+			of = false
+			cf = r&1 != 0
+			r = r>>1 | r&sign
+		case "rol":
+			cf = r&sign != 0
+			r = (r<<1)&mask | b2u(cf)
+			of = (r&sign != 0) != cf
+		case "ror":
+			cf = r&1 != 0
+			r = r>>1 | b2u(cf)*sign
+			of = (r&sign != 0) != (r&(sign>>1) != 0)
+		case "rcl":
+			out := r&sign != 0
+			r = (r<<1)&mask | b2u(cf)
+			cf = out
+			of = (r&sign != 0) != cf
+		case "rcr":
+			out := r&1 != 0
+			r = r>>1 | b2u(cf)*sign
+			cf = out
+			of = (r&sign != 0) != (r&(sign>>1) != 0)
+		}
+	}
+	s.Flags.setTo(flagCF, cf)
+	s.Flags.setTo(flagOF, of)
+	switch name {
+	case "shl", "sal", "shr", "sar":
+		s.setSZP(w, r)
+	}
+	return uint16(r)
+}
+
+func b2u(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// adjust runs one of the decimal adjust instructions on al, or ax for the
+// ASCII adjusts. daa and das adjust packed BCD after an add or sub, aaa and
+// aas unpacked BCD, and aam and aad unpacked BCD after a mul or before a div.
+// Flags the manual says are undefined are left alone.
+func (s *Simulator) adjust(name string) {
+	al, ah := s.Reg(decoder.AL), s.Reg(decoder.AH)
+	cf, af := s.Flags.isSet(flagCF), s.Flags.isSet(flagAF)
+	switch name {
+	case "daa", "das":
+		// Both adjustments are decided by al before either is made
+		lowAdjust := al&0x0f > 9 || af
+		highAdjust := al > 0x99 || cf
+		if lowAdjust && name == "daa" {
+			al += 6
+		} else if lowAdjust {
+			al -= 6
+		}
+		if highAdjust && name == "daa" {
+			al += 0x60
+		} else if highAdjust {
+			al -= 0x60
+		}
+		al &= 0xff
+		s.Flags.setTo(flagAF, lowAdjust)
+		s.Flags.setTo(flagCF, highAdjust)
+		s.setSZP(1, uint32(al))
+	case "aaa", "aas":
+		adjust := al&0x0f > 9 || af
+		if adjust && name == "aaa" {
+			al += 6
+			ah++
+		} else if adjust {
+			al -= 6
+			ah--
+		}
+		al &= 0x0f
+		s.Flags.setTo(flagAF, adjust)
+		s.Flags.setTo(flagCF, adjust)
+	case "aam":
+		ah, al = al/10, al%10
+		s.setSZP(1, uint32(al))
+	case "aad":
+		al = (al + ah*10) & 0xff
+		ah = 0
+		s.setSZP(1, uint32(al))
+	}
+	s.SetReg(decoder.AL, al)
+	s.SetReg(decoder.AH, ah)
+}
+
+// errDivide is the 8086's divide error, raised when dividing by zero or when
+// the quotient doesn't fit in the destination.
+var errDivide = errors.New("divide error")
+
+// multiply runs mul or imul of the accumulator by src, which is w bytes wide,
+// putting the result in ax, or dx:ax for words. CF and OF are set if the
+// upper half of the result is needed; SF, ZF, AF and PF are undefined and
+// left alone.
+func (s *Simulator) multiply(name string, w int, src uint16) {
+	var upper bool
+	if w == 1 {
+		al := s.Reg(decoder.AL)
+		var r uint16
+		if name == "imul" {
+			r = uint16(int16(int8(al)) * int16(int8(src)))
+			upper = int16(r) != int16(int8(r))
+		} else {
+			r = al * (src & 0xff)
+			upper = r > 0xff
+		}
+		s.SetReg(decoder.AX, r)
+	} else {
+		ax := s.Reg(decoder.AX)
+		var r uint32
+		if name == "imul" {
+			r = uint32(int32(int16(ax)) * int32(int16(src)))
+			upper = int32(r) != int32(int16(r))
+		} else {
+			r = uint32(ax) * uint32(src)
+			upper = r > 0xffff
+		}
+		s.SetReg(decoder.AX, uint16(r))
+		s.SetReg(decoder.DX, uint16(r>>16))
+	}
+	s.Flags.setTo(flagCF, upper)
+	s.Flags.setTo(flagOF, upper)
+}
+
+// divide runs div or idiv of ax, or dx:ax for words, by src, which is w bytes
+// wide, putting the quotient in al or ax and the remainder in ah or dx. It
+// returns errDivide without changing anything if src is 0 or the quotient
+// doesn't fit. The 8086's idiv can't give the most negative quotient, -128 or
+// -32768. The flags are undefined and left alone.
+func (s *Simulator) divide(name string, w int, src uint16) error {
+	var dividend uint32
+	if w == 1 {
+		dividend = uint32(s.Reg(decoder.AX))
+		src &= 0xff
+	} else {
+		dividend = uint32(s.Reg(decoder.DX))<<16 | uint32(s.Reg(decoder.AX))
+	}
+	if src == 0 {
+		return errDivide
+	}
+
+	var q, r uint32
+	if name == "idiv" {
+		var n, d int32
+		if w == 1 {
+			n, d = int32(int16(dividend)), int32(int8(src))
+		} else {
+			n, d = int32(dividend), int32(int16(src))
+		}
+		limit := int32(signBit(w)) - 1
+		sq := n / d
+		if sq > limit || sq < -limit {
+			return errDivide
+		}
+		q, r = uint32(sq), uint32(n%d)
+	} else {
+		q, r = dividend/uint32(src), dividend%uint32(src)
+		if q > widthMask(w) {
+			return errDivide
+		}
+	}
+
+	if w == 1 {
+		s.SetReg(decoder.AL, uint16(q))
+		s.SetReg(decoder.AH, uint16(r))
+	} else {
+		s.SetReg(decoder.AX, uint16(q))
+		s.SetReg(decoder.DX, uint16(r))
+	}
+	return nil
+}
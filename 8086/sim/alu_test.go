@@ -0,0 +1,165 @@
+package sim
+
+import (
+	"testing"
+
+	"8086/decoder"
+)
+
+func TestALU(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    func(s *Simulator) uint16
+		flags Flags // before
+		want  uint16
+		wantF string
+	}{
+		// add sets every arithmetic flag, from the width of the operands
+		{"add 8", func(s *Simulator) uint16 { return s.add(1, 0x7f, 0x01, false) }, 0, 0x80, "ASO"},
+		{"add 8 carry", func(s *Simulator) uint16 { return s.add(1, 0xff, 0x01, false) }, 0, 0x00, "CPAZ"},
+		{"add 16", func(s *Simulator) uint16 { return s.add(2, 0xff, 0x01, false) }, 0, 0x100, "PA"},
+		{"add 16 carry", func(s *Simulator) uint16 { return s.add(2, 0x8000, 0x8000, false) }, 0, 0, "CPZO"},
+		{"adc", func(s *Simulator) uint16 { return s.add(2, 0x00c8, 0x03e8, true) }, flagCF, 0x04b1, "PA"},
+		{"add parity of low byte", func(s *Simulator) uint16 { return s.add(2, 0x0100, 0x0003, false) }, 0, 0x0103, "P"},
+
+		// sub and cmp
+		{"sub borrow", func(s *Simulator) uint16 { return s.sub(2, 0x04b0, 0x07d0, false) }, 0, 0xfce0, "CS"},
+		{"sub zero", func(s *Simulator) uint16 { return s.sub(2, 0x07ea, 0x07ea, false) }, 0, 0, "PZ"},
+		{"sub overflow", func(s *Simulator) uint16 { return s.sub(1, 0x80, 0x01, false) }, 0, 0x7f, "AO"},
+		{"sbb", func(s *Simulator) uint16 { return s.sub(1, 0x10, 0x0f, true) }, flagCF, 0x00, "PAZ"},
+		{"sbb borrow", func(s *Simulator) uint16 { return s.sub(1, 0x00, 0xff, true) }, flagCF, 0x00, "CPAZ"},
+
+		// inc and dec leave CF alone
+		{"inc", func(s *Simulator) uint16 { return s.inc(2, 0xffff) }, flagCF, 0, "CPAZ"},
+		{"inc no carry", func(s *Simulator) uint16 { return s.inc(1, 0xff) }, 0, 0, "PAZ"},
+		{"inc overflow", func(s *Simulator) uint16 { return s.inc(1, 0x7f) }, 0, 0x80, "ASO"},
+		{"dec", func(s *Simulator) uint16 { return s.dec(2, 0) }, 0, 0xffff, "PAS"},
+		{"dec overflow", func(s *Simulator) uint16 { return s.dec(2, 0x8000) }, flagCF, 0x7fff, "CPAO"},
+
+		// neg sets CF unless the operand is 0
+		{"neg", func(s *Simulator) uint16 { return s.neg(1, 0x01) }, 0, 0xff, "CPAS"},
+		{"neg zero", func(s *Simulator) uint16 { return s.neg(2, 0) }, flagCF, 0, "PZ"},
+		{"neg overflow", func(s *Simulator) uint16 { return s.neg(2, 0x8000) }, 0, 0x8000, "CPSO"},
+
+		// and, or, xor and test clear CF, OF and AF
+		{"logic", func(s *Simulator) uint16 { return s.logic(1, 0x80) }, flagCF | flagOF | flagAF, 0x80, "S"},
+		{"logic zero", func(s *Simulator) uint16 { return s.logic(2, 0) }, flagCF, 0, "PZ"},
+
+		// shifts and rotates
+		{"shl 1", func(s *Simulator) uint16 { return s.shift("shl", 1, 0xc0, 1) }, 0, 0x80, "CS"},
+		{"shl 1 overflow", func(s *Simulator) uint16 { return s.shift("shl", 2, 0x4000, 1) }, 0, 0x8000, "PSO"},
+		{"shl 9", func(s *Simulator) uint16 { return s.shift("shl", 1, 0x01, 9) }, 0, 0, "PZ"},
+		{"shl 0", func(s *Simulator) uint16 { return s.shift("shl", 1, 0x01, 0) }, flagCF, 0x01, "C"},
+		{"shr 1", func(s *Simulator) uint16 { return s.shift("shr", 2, 0x8001, 1) }, 0, 0x4000, "CPO"},
+		{"sar 1", func(s *Simulator) uint16 { return s.shift("sar", 1, 0x81, 1) }, 0, 0xc0, "CPS"},
+		{"sar 3", func(s *Simulator) uint16 { return s.shift("sar", 2, 0x8004, 3) }, 0, 0xf000, "CPS"},
+		{"rol 1", func(s *Simulator) uint16 { return s.shift("rol", 1, 0x81, 1) }, flagZF, 0x03, "CZO"},
+		{"ror 1", func(s *Simulator) uint16 { return s.shift("ror", 2, 0x0001, 1) }, 0, 0x8000, "CO"},
+		{"rol 16", func(s *Simulator) uint16 { return s.shift("rol", 2, 0x1234, 16) }, 0, 0x1234, ""},
+		{"rcl 1", func(s *Simulator) uint16 { return s.shift("rcl", 1, 0x80, 1) }, flagCF, 0x01, "CO"},
+		{"rcr 1", func(s *Simulator) uint16 { return s.shift("rcr", 1, 0x01, 1) }, flagCF, 0x80, "CO"},
+		{"rcl 9", func(s *Simulator) uint16 { return s.shift("rcl", 1, 0x55, 9) }, 0, 0x55, ""},
+	}
+	for _, tt := range tests {
+		s := New()
+		s.Flags = tt.flags
+		got := tt.op(s)
+		if got != tt.want || s.Flags.String() != tt.wantF {
+			t.Errorf("%s: got %#x flags %q, want %#x flags %q", tt.name, got, s.Flags, tt.want, tt.wantF)
+		}
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	tests := []struct {
+		name   string
+		ax     uint16
+		flags  Flags
+		wantAX uint16
+		wantF  string
+	}{
+		// 0x19 + 0x28 = 0x41, which is 47 in BCD
+		{"daa", 0x0041, flagAF, 0x0047, "PA"},
+		// 0x99 + 0x01 = 0x9a, which is 100 in BCD
+		{"daa", 0x009a, 0, 0x0000, "CPAZ"},
+		// 0x47 - 0x28 = 0x1f, which is 19 in BCD
+		{"das", 0x001f, flagAF, 0x0019, "A"},
+		// 0x10 - 0x20 = 0xf0, which is 90 borrowing 100
+		{"das", 0x00f0, flagCF, 0x0090, "CPS"},
+		// 9 + 5 = 0x0e, which is 1 4 unpacked
+		{"aaa", 0x000e, 0, 0x0104, "CA"},
+		{"aaa", 0x0005, flagCF, 0x0005, ""},
+		// 0x0103 - 5 = 0x01fe, which is 0 8 unpacked
+		{"aas", 0x01fe, flagCF | flagAF, 0x0008, "CA"},
+		{"aam", 0x003f, 0, 0x0603, "P"},
+		{"aam", 0x0000, 0, 0x0000, "PZ"},
+		{"aad", 0x0603, 0, 0x003f, "P"},
+		{"aad", 0x0909, flagCF, 0x0063, "CP"},
+	}
+	for _, tt := range tests {
+		s := New()
+		s.SetReg(decoder.AX, tt.ax)
+		s.Flags = tt.flags
+		s.adjust(tt.name)
+		if got := s.Reg(decoder.AX); got != tt.wantAX || s.Flags.String() != tt.wantF {
+			t.Errorf("%s %#04x: got %#04x flags %q, want %#04x flags %q", tt.name, tt.ax, got, s.Flags, tt.wantAX, tt.wantF)
+		}
+	}
+}
+
+func TestMultiplyDivide(t *testing.T) {
+	tests := []struct {
+		name       string
+		w          int
+		ax, dx     uint16
+		src        uint16
+		wantAX     uint16
+		wantDX     uint16
+		wantF      string
+		wantDivErr bool
+	}{
+		{name: "mul", w: 1, ax: 0x0010, src: 0x0f, wantAX: 0x00f0},
+		{name: "mul", w: 1, ax: 0x0010, src: 0x10, wantAX: 0x0100, wantF: "CO"},
+		{name: "mul", w: 2, ax: 0x1000, dx: 0xffff, src: 0x0010, wantAX: 0x0000, wantDX: 0x0001, wantF: "CO"},
+		{name: "mul", w: 2, ax: 0xffff, src: 0x0001, wantAX: 0xffff},
+		{name: "imul", w: 1, ax: 0x00ff, src: 0x02, wantAX: 0xfffe},
+		{name: "imul", w: 1, ax: 0x0040, src: 0x02, wantAX: 0x0080, wantF: "CO"},
+		{name: "imul", w: 2, ax: 0xffff, src: 0xffff, wantAX: 0x0001},
+		{name: "imul", w: 2, ax: 0x8000, src: 0xffff, wantAX: 0x8000, wantDX: 0x0000, wantF: "CO"},
+		{name: "imul", w: 2, ax: 0xfffe, src: 0x4000, wantAX: 0x8000, wantDX: 0xffff},
+
+		{name: "div", w: 1, ax: 0x0107, src: 0x10, wantAX: 0x0710},
+		{name: "div", w: 2, ax: 0x0005, dx: 0x0001, src: 0x0010, wantAX: 0x1000, wantDX: 0x0005},
+		{name: "div", w: 1, ax: 0x1000, src: 0x10, wantDivErr: true},
+		{name: "div", w: 2, ax: 0x0000, dx: 0x0010, src: 0x0010, wantDivErr: true},
+		{name: "div", w: 2, ax: 0x1234, src: 0, wantDivErr: true},
+		{name: "idiv", w: 1, ax: 0xfff9, src: 0x02, wantAX: 0xfffd},
+		{name: "idiv", w: 2, ax: 0xfff9, dx: 0xffff, src: 0xfffe, wantAX: 0x0003, wantDX: 0xffff},
+		{name: "idiv", w: 1, ax: 0x007f, src: 0x01, wantAX: 0x007f},
+		{name: "idiv", w: 1, ax: 0xff80, src: 0x01, wantDivErr: true},
+		{name: "idiv", w: 2, ax: 0x8000, dx: 0x0000, src: 0x0001, wantDivErr: true},
+	}
+	for _, tt := range tests {
+		s := New()
+		s.SetReg(decoder.AX, tt.ax)
+		s.SetReg(decoder.DX, tt.dx)
+		if tt.name == "mul" || tt.name == "imul" {
+			s.multiply(tt.name, tt.w, tt.src)
+		} else if err := s.divide(tt.name, tt.w, tt.src); err != nil {
+			if !tt.wantDivErr {
+				t.Errorf("%s %d %#04x:%#04x / %#04x: %v", tt.name, tt.w, tt.dx, tt.ax, tt.src, err)
+			} else if s.Reg(decoder.AX) != tt.ax || s.Reg(decoder.DX) != tt.dx {
+				t.Errorf("%s %d %#04x:%#04x / %#04x: changed the registers on a divide error", tt.name, tt.w, tt.dx, tt.ax, tt.src)
+			}
+			continue
+		} else if tt.wantDivErr {
+			t.Errorf("%s %d %#04x:%#04x / %#04x: no divide error", tt.name, tt.w, tt.dx, tt.ax, tt.src)
+			continue
+		}
+		ax, dx := s.Reg(decoder.AX), s.Reg(decoder.DX)
+		if ax != tt.wantAX || dx != tt.wantDX || s.Flags.String() != tt.wantF {
+			t.Errorf("%s %d %#04x:%#04x, %#04x: got %#04x:%#04x flags %q, want %#04x:%#04x flags %q",
+				tt.name, tt.w, tt.dx, tt.ax, tt.src, dx, ax, s.Flags, tt.wantDX, tt.wantAX, tt.wantF)
+		}
+	}
+}
@@ -0,0 +1,692 @@
+// Package sim simulates the 8086 running the instructions of package decoder,
+// with the registers, flags and the 1 MiB of memory they work on.
+package sim
+
+import (
+	"fmt"
+	"strings"
+
+	"8086/decoder"
+)
+
+// memorySize is the 8086's 1 MiB address space.
+const memorySize = 1 << 20
+
+// Simulator is the state of an 8086. Its zero value has no memory, so use New.
+type Simulator struct {
+	IP uint16
+
+	// Regs are the registers by decoder.Register.Index, which Reg and SetReg
+	// read and write a byte or word of
+	Regs  [12]uint16
+	Flags Flags
+
+	mem []byte
+	// base is the physical address the program was loaded at
+	base int
+
+	// Stack is the pushes and pops made by the last instruction
+	Stack []StackOp
+	// Repeats is the number of times the last instruction ran, if it was a
+	// string instruction with a rep prefix
+	Repeats int
+	// Taken is set if the last instruction was a conditional jump, loop or
+	// into that was taken, even if it went to the next instruction anyway
+	Taken bool
+
+	// Halted is set by hlt, after which nothing more is run until an
+	// interrupt
+	Halted bool
+
+	// pending are the interrupts raised while IF was clear, in the order
+	// they're taken once it's set
+	pending []byte
+}
+
+// New returns a simulator with its memory and registers all zero.
+func New() *Simulator {
+	return &Simulator{mem: make([]byte, memorySize)}
+}
+
+// Load copies program into memory at cs:ip and returns the memory it's in,
+// so that it can be decoded from there and see its own writes.
+func (s *Simulator) Load(program []byte) []byte {
+	s.base = s.physical(s.Reg(decoder.CS), s.IP)
+	n := copy(s.mem[s.base:], program)
+	return s.mem[s.base : s.base+n]
+}
+
+// PC returns the offset of cs:ip in the loaded program, which is negative or
+// past its end once a jump or call has left it.
+func (s *Simulator) PC() int {
+	return s.physical(s.Reg(decoder.CS), s.IP) - s.base
+}
+
+// ClockParams fills in what the registers tell about in's clocks before it's
+// executed: the address of its memory operand, and the count of a shift by cl
+// or a repeated string instruction.
+func (s *Simulator) ClockParams(in decoder.Instruction, p *decoder.ClockParams) {
+	for _, o := range in.Operands() {
+		switch {
+		case o.Kind == decoder.OperandMemory:
+			_, p.Address = s.address(o.EA)
+			p.AddressKnown = true
+		case o.Kind == decoder.OperandRegister && o.Reg == decoder.CL && o.UnknownSize:
+			p.Count, p.CountKnown = int(s.Reg(decoder.CL)), true
+		}
+	}
+	if in.FlagSet(decoder.FlagRepeat) || in.FlagSet(decoder.FlagRepeatZ) {
+		p.Count, p.CountKnown = int(s.Reg(decoder.CX)), true
+	}
+}
+
+// Exec runs in, which was decoded at cs:ip, leaving IP at the next
+// instruction to run. A pending interrupt is taken after it if IF is set.
+func (s *Simulator) Exec(in decoder.Instruction) error {
+	if err := s.exec(in); err != nil {
+		return err
+	}
+	// sti only lets interrupts in after the instruction that follows it, so
+	// that sti; ret returns before the handler runs
+	if len(s.pending) > 0 && s.Flags.isSet(flagIF) && in.Name != "sti" {
+		n := s.pending[0]
+		s.pending = s.pending[1:]
+		s.interrupt(n)
+		s.Halted = false
+	}
+	return nil
+}
+
+func (s *Simulator) exec(in decoder.Instruction) error {
+	s.IP += uint16(in.Length)
+	s.Stack = s.Stack[:0]
+	s.Repeats = 0
+	s.Taken = false
+
+	if j, ok := jumps[in.Name]; ok {
+		if s.jumpTaken(j) {
+			s.IP += uint16(in.JumpTarget)
+			s.Taken = true
+		}
+		return nil
+	}
+
+	ops := in.Operands()
+	w := width(in, ops)
+
+	switch in.Name {
+	case "mov":
+		s.write(ops[0], w, s.read(ops[1], w))
+	case "xchg":
+		a, b := s.read(ops[0], w), s.read(ops[1], w)
+		s.write(ops[0], w, b)
+		s.write(ops[1], w, a)
+
+	case "add", "adc", "sub", "sbb", "cmp", "and", "or", "xor", "test":
+		a, b := s.read(ops[0], w), s.read(ops[1], w)
+		cf := s.Flags.isSet(flagCF)
+		var r uint16
+		switch in.Name {
+		case "add", "adc":
+			r = s.add(w, a, b, in.Name == "adc" && cf)
+		case "sub", "sbb", "cmp":
+			r = s.sub(w, a, b, in.Name == "sbb" && cf)
+		case "and", "test":
+			r = s.logic(w, a&b)
+		case "or":
+			r = s.logic(w, a|b)
+		case "xor":
+			r = s.logic(w, a^b)
+		}
+		if in.Name != "cmp" && in.Name != "test" {
+			s.write(ops[0], w, r)
+		}
+	case "inc":
+		s.write(ops[0], w, s.inc(w, s.read(ops[0], w)))
+	case "dec":
+		s.write(ops[0], w, s.dec(w, s.read(ops[0], w)))
+	case "neg":
+		s.write(ops[0], w, s.neg(w, s.read(ops[0], w)))
+	case "not":
+		s.write(ops[0], w, ^s.read(ops[0], w))
+
+	case "lea", "lds", "les":
+		if ops[1].Kind != decoder.OperandMemory {
+			return fmt.Errorf("invalid instruction %s: the source must be memory", in)
+		}
+		seg, off := s.address(ops[1].EA)
+		switch in.Name {
+		case "lea":
+			s.SetReg(ops[0].Reg, off)
+		case "lds":
+			s.SetReg(ops[0].Reg, s.loadWord(seg, off))
+			s.SetReg(decoder.DS, s.loadWord(seg, off+2))
+		case "les":
+			s.SetReg(ops[0].Reg, s.loadWord(seg, off))
+			s.SetReg(decoder.ES, s.loadWord(seg, off+2))
+		}
+	case "xlat":
+		ea := decoder.EffectiveAddress{
+			Base:         decoder.BX,
+			Displacement: int16(s.Reg(decoder.AL)),
+			Segment:      in.SegmentOverride(),
+		}
+		s.SetReg(decoder.AL, uint16(s.loadByte(s.address(ea))))
+
+	case "cbw":
+		s.SetReg(decoder.AX, uint16(int8(s.Reg(decoder.AL))))
+	case "cwd":
+		var dx uint16
+		if s.Reg(decoder.AX)&0x8000 != 0 {
+			dx = 0xffff
+		}
+		s.SetReg(decoder.DX, dx)
+
+	case "lahf":
+		// Bit 1 of FLAGS always reads as 1
+		s.SetReg(decoder.AH, uint16(s.Flags&lowFlags|0x02))
+	case "sahf":
+		s.Flags = s.Flags&^lowFlags | Flags(s.Reg(decoder.AH))&lowFlags
+	case "clc":
+		s.Flags &^= flagCF
+	case "stc":
+		s.Flags |= flagCF
+	case "cmc":
+		s.Flags ^= flagCF
+	case "cld":
+		s.Flags &^= flagDF
+	case "std":
+		s.Flags |= flagDF
+	case "cli":
+		s.Flags &^= flagIF
+	case "sti":
+		s.Flags |= flagIF
+
+	case "shl", "shr", "sar", "rol", "ror", "rcl", "rcr":
+		// The count is 1 or cl, which the 8086 doesn't mask
+		count := int(s.read(ops[1], 1))
+		s.write(ops[0], w, s.shift(in.Name, w, s.read(ops[0], w), count))
+
+	case "mul", "imul":
+		s.multiply(in.Name, w, s.read(ops[0], w))
+	case "div", "idiv":
+		if err := s.divide(in.Name, w, s.read(ops[0], w)); err != nil {
+			// The 8086 returns from a divide error to the next instruction
+			s.interrupt(0)
+		}
+
+	case "push":
+		v := s.read(ops[0], 2)
+		if ops[0].Kind == decoder.OperandRegister && ops[0].Reg == decoder.SP {
+			// The 8086 pushes sp after it's decremented
+			v -= 2
+		}
+		s.push(v)
+	case "pop":
+		s.write(ops[0], 2, s.pop())
+	case "pushf":
+		s.push(uint16(s.Flags) | unusedFlags)
+	case "popf":
+		s.Flags = Flags(s.pop()) & allFlags
+
+	case "call", "jmp":
+		if ops[0].Far && ops[0].Kind != decoder.OperandMemory {
+			return fmt.Errorf("invalid instruction %s: the target must be memory", in)
+		}
+		seg, off, far := s.target(ops[0])
+		if in.Name == "call" {
+			if far {
+				s.push(s.Reg(decoder.CS))
+			}
+			s.push(s.IP)
+		}
+		if far {
+			s.SetReg(decoder.CS, seg)
+		}
+		s.IP = off
+	case "ret", "retf":
+		s.IP = s.pop()
+		if in.Name == "retf" {
+			s.SetReg(decoder.CS, s.pop())
+		}
+		if len(ops) > 0 {
+			// ret imm16 also drops the caller's arguments
+			s.SetReg(decoder.SP, s.Reg(decoder.SP)+ops[0].Imm)
+		}
+
+	case "movsb", "movsw", "cmpsb", "cmpsw", "scasb", "scasw", "lodsb", "lodsw", "stosb", "stosw":
+		// These have no W field, so the size is in the name
+		w = 1
+		if strings.HasSuffix(in.Name, "w") {
+			w = 2
+		}
+		n := s.stringOp(in, w)
+		if in.FlagSet(decoder.FlagRepeat) || in.FlagSet(decoder.FlagRepeatZ) {
+			s.Repeats = n
+		}
+
+	case "int":
+		s.interrupt(byte(ops[0].Imm))
+	case "int3":
+		s.interrupt(3)
+	case "into":
+		if s.Flags.isSet(flagOF) {
+			s.interrupt(4)
+			s.Taken = true
+		}
+	case "iret":
+		s.IP = s.pop()
+		s.SetReg(decoder.CS, s.pop())
+		s.Flags = Flags(s.pop()) & allFlags
+
+	case "daa", "das", "aaa", "aas", "aam", "aad":
+		s.adjust(in.Name)
+
+	case "hlt":
+		s.Halted = true
+	default:
+		return fmt.Errorf("unimplemented instruction %s", in)
+	}
+	return nil
+}
+
+// interrupt calls the handler of interrupt n, whose far pointer is at
+// 0000:n*4 in the interrupt vector table. FLAGS, cs and ip are pushed for
+// iret to return with, and IF and TF are cleared so that the handler isn't
+// interrupted or single stepped.
+func (s *Simulator) interrupt(n byte) {
+	s.push(uint16(s.Flags) | unusedFlags)
+	s.push(s.Reg(decoder.CS))
+	s.push(s.IP)
+	s.Flags &^= flagIF | flagTF
+	s.SetReg(decoder.CS, s.loadWord(0, uint16(n)*4+2))
+	s.IP = s.loadWord(0, uint16(n)*4)
+}
+
+// RaiseInterrupt raises external interrupt n between instructions, as the
+// timer or keyboard would, and reports whether it was taken then. It's only
+// taken if IF is set; otherwise it's held pending, like an interrupt
+// controller would, and taken by Exec after the first instruction that runs
+// with IF set, in the order they were raised. Taking it wakes the simulator
+// from hlt.
+func (s *Simulator) RaiseInterrupt(n byte) bool {
+	if !s.Flags.isSet(flagIF) {
+		s.pending = append(s.pending, n)
+		return false
+	}
+	s.Stack = s.Stack[:0]
+	s.interrupt(n)
+	s.Halted = false
+	return true
+}
+
+// jump is the condition of a conditional jump, loop or jcxz.
+type jump struct {
+	// flags is the test of the flags, or nil if there isn't one
+	flags func(f Flags) bool
+	// loop decrements cx first, and only jumps if it isn't then 0
+	loop bool
+	// cxz only jumps if cx is 0
+	cxz bool
+}
+
+// jumps is the condition of each conditional jump, by the name the decoder
+// gives it.
+// - https://www.tutorialspoint.com/assembly_programming/assembly_conditions.htm
+// - https://stackoverflow.com/questions/53451732/js-and-jb-instructions-in-assembly
+/*
+	Mnemonic        Condition tested  Description
+	jo              OF = 1            overflow
+	jno             OF = 0            not overflow
+	jc, jb, jnae    CF = 1            carry / below / not above nor equal
+	jnc, jae, jnb   CF = 0            not carry / above or equal / not below
+	je, jz          ZF = 1            equal / zero
+	jne, jnz        ZF = 0            not equal / not zero
+	jbe, jna        CF or ZF = 1      below or equal / not above
+	ja, jnbe        CF or ZF = 0      above / not below or equal
+	js              SF = 1            sign
+	jns             SF = 0            not sign
+	jp, jpe         PF = 1            parity / parity even
+	jnp, jpo        PF = 0            not parity / parity odd
+	jl, jnge        SF xor OF = 1     less / not greater nor equal
+	jge, jnl        SF xor OF = 0     greater or equal / not less
+	jle, jng    (SF xor OF) or ZF = 1 less or equal / not greater
+	jg, jnle    (SF xor OF) or ZF = 0 greater / not less nor equal
+
+	loop            CX - 1 != 0       loop
+	loopz, loope    CX - 1 != 0       loop while zero / equal
+	                and ZF = 1
+	loopnz, loopne  CX - 1 != 0       loop while not zero / not equal
+	                and ZF = 0
+	jcxz            CX = 0            CX is zero
+*/
+var jumps = map[string]jump{
+	"jo":   {flags: func(f Flags) bool { return f.isSet(flagOF) }},
+	"jno":  {flags: func(f Flags) bool { return !f.isSet(flagOF) }},
+	"jb":   {flags: func(f Flags) bool { return f.isSet(flagCF) }},
+	"jnb":  {flags: func(f Flags) bool { return !f.isSet(flagCF) }},
+	"je":   {flags: func(f Flags) bool { return f.isSet(flagZF) }},
+	"jne":  {flags: func(f Flags) bool { return !f.isSet(flagZF) }},
+	"jbe":  {flags: func(f Flags) bool { return f.isSet(flagCF) || f.isSet(flagZF) }},
+	"jnbe": {flags: func(f Flags) bool { return !f.isSet(flagCF) && !f.isSet(flagZF) }},
+	"js":   {flags: func(f Flags) bool { return f.isSet(flagSF) }},
+	"jns":  {flags: func(f Flags) bool { return !f.isSet(flagSF) }},
+	"jp":   {flags: func(f Flags) bool { return f.isSet(flagPF) }},
+	"jnp":  {flags: func(f Flags) bool { return !f.isSet(flagPF) }},
+	"jl":   {flags: func(f Flags) bool { return f.isSet(flagSF) != f.isSet(flagOF) }},
+	"jge":  {flags: func(f Flags) bool { return f.isSet(flagSF) == f.isSet(flagOF) }},
+	"jle":  {flags: func(f Flags) bool { return f.isSet(flagSF) != f.isSet(flagOF) || f.isSet(flagZF) }},
+	"jg":   {flags: func(f Flags) bool { return f.isSet(flagSF) == f.isSet(flagOF) && !f.isSet(flagZF) }},
+
+	"loop":   {loop: true},
+	"loopz":  {loop: true, flags: func(f Flags) bool { return f.isSet(flagZF) }},
+	"loopnz": {loop: true, flags: func(f Flags) bool { return !f.isSet(flagZF) }},
+	"jcxz":   {cxz: true},
+}
+
+// jumpTaken reports whether j jumps, decrementing cx first for a loop, which
+// doesn't change the flags.
+func (s *Simulator) jumpTaken(j jump) bool {
+	cx := s.Reg(decoder.CX)
+	if j.loop {
+		cx--
+		s.SetReg(decoder.CX, cx)
+		if cx == 0 {
+			return false
+		}
+	}
+	if j.cxz && cx != 0 {
+		return false
+	}
+	return j.flags == nil || j.flags(s.Flags)
+}
+
+// target returns where a call or jmp to o goes. A relative target is near,
+// a register or memory operand holds the offset of a near one, and a far
+// pointer or a far memory operand gives the segment too.
+func (s *Simulator) target(o decoder.Operand) (seg, off uint16, far bool) {
+	switch {
+	case o.Kind == decoder.OperandRelative:
+		return 0, s.IP + uint16(o.JumpTarget), false
+	case o.Kind == decoder.OperandFarPointer:
+		return o.Segment, o.Imm, true
+	case o.Far:
+		seg, off := s.address(o.EA)
+		return s.loadWord(seg, off+2), s.loadWord(seg, off), true
+	}
+	return 0, s.read(o, 2), false
+}
+
+// stringOp runs a string instruction on w byte elements, from ds:si, or the
+// segment of an override, to es:di, stepping si and di down if DF is set and
+// up otherwise. With a rep prefix it runs until cx is 0, decrementing it each
+// time, and cmps and scas also stop once ZF is clear for repe or set for
+// repne. It returns the number of times it ran.
+func (s *Simulator) stringOp(in decoder.Instruction, w int) int {
+	op := strings.TrimRight(in.Name, "bw")
+	rep := in.FlagSet(decoder.FlagRepeat) || in.FlagSet(decoder.FlagRepeatZ)
+	src := in.SegmentOverride()
+	if src == decoder.RegNone {
+		src = decoder.DS
+	}
+	acc := decoder.AX
+	if w == 1 {
+		acc = decoder.AL
+	}
+	step := uint16(w)
+	if s.Flags.isSet(flagDF) {
+		step = -step
+	}
+
+	n := 0
+	for !rep || s.Reg(decoder.CX) != 0 {
+		seg, si := s.Reg(src), s.Reg(decoder.SI)
+		es, di := s.Reg(decoder.ES), s.Reg(decoder.DI)
+		switch op {
+		case "movs":
+			s.storeWidth(w, es, di, s.loadWidth(w, seg, si))
+		case "cmps":
+			s.sub(w, s.loadWidth(w, seg, si), s.loadWidth(w, es, di), false)
+		case "scas":
+			s.sub(w, s.Reg(acc), s.loadWidth(w, es, di), false)
+		case "lods":
+			s.SetReg(acc, s.loadWidth(w, seg, si))
+		case "stos":
+			s.storeWidth(w, es, di, s.Reg(acc))
+		}
+		if op == "movs" || op == "cmps" || op == "lods" {
+			s.SetReg(decoder.SI, si+step)
+		}
+		if op != "lods" {
+			s.SetReg(decoder.DI, di+step)
+		}
+		n++
+
+		if !rep {
+			break
+		}
+		s.SetReg(decoder.CX, s.Reg(decoder.CX)-1)
+		if (op == "cmps" || op == "scas") && s.Flags.isSet(flagZF) != in.FlagSet(decoder.FlagRepeatZ) {
+			break
+		}
+	}
+	return n
+}
+
+// width returns the size in bytes of the data an instruction operates on,
+// which is the size of its registers if it has any, otherwise its W field.
+func width(in decoder.Instruction, ops []decoder.Operand) int {
+	for _, o := range ops {
+		if (o.Kind == decoder.OperandRegister || o.Kind == decoder.OperandSegment) && !o.UnknownSize {
+			return o.Reg.Width()
+		}
+	}
+	return int(in.W) + 1
+}
+
+// read returns the value of a register, memory or immediate operand that is
+// w bytes wide.
+func (s *Simulator) read(o decoder.Operand, w int) uint16 {
+	switch o.Kind {
+	case decoder.OperandRegister, decoder.OperandSegment:
+		return s.Reg(o.Reg)
+	case decoder.OperandMemory:
+		seg, off := s.address(o.EA)
+		return s.loadWidth(w, seg, off)
+	case decoder.OperandImmediate:
+		return o.Imm
+	}
+	panic(fmt.Sprintf("can't read %v operand", o.Kind))
+}
+
+// write stores v in a register or memory operand that is w bytes wide.
+func (s *Simulator) write(o decoder.Operand, w int, v uint16) {
+	switch o.Kind {
+	case decoder.OperandRegister, decoder.OperandSegment:
+		s.SetReg(o.Reg, v)
+	case decoder.OperandMemory:
+		seg, off := s.address(o.EA)
+		s.storeWidth(w, seg, off, v)
+	default:
+		panic(fmt.Sprintf("can't write %v operand", o.Kind))
+	}
+}
+
+// address returns the segment and offset of an effective address, in the
+// segment given by an override or else ds, or ss for bp based addresses.
+func (s *Simulator) address(ea decoder.EffectiveAddress) (seg, off uint16) {
+	off = uint16(ea.Displacement)
+	if ea.Base != decoder.RegNone {
+		off += s.Reg(ea.Base)
+	}
+	if ea.Index != decoder.RegNone {
+		off += s.Reg(ea.Index)
+	}
+	return s.Reg(ea.DefaultSegment()), off
+}
+
+// physical returns the 20-bit address of seg:off, wrapping around at 1 MiB
+// like the 8086.
+func (s *Simulator) physical(seg, off uint16) int {
+	return (int(seg)<<4 + int(off)) & (memorySize - 1)
+}
+
+func (s *Simulator) loadByte(seg, off uint16) byte {
+	return s.mem[s.physical(seg, off)]
+}
+
+func (s *Simulator) storeByte(seg, off uint16, v byte) {
+	s.mem[s.physical(seg, off)] = v
+}
+
+// loadWord returns the little endian word at seg:off. The offset of the high
+// byte wraps around within the segment.
+func (s *Simulator) loadWord(seg, off uint16) uint16 {
+	return uint16(s.loadByte(seg, off)) | uint16(s.loadByte(seg, off+1))<<8
+}
+
+func (s *Simulator) storeWord(seg, off uint16, v uint16) {
+	s.storeByte(seg, off, byte(v))
+	s.storeByte(seg, off+1, byte(v>>8))
+}
+
+// loadWidth returns the byte or word at seg:off, for w of 1 or 2.
+func (s *Simulator) loadWidth(w int, seg, off uint16) uint16 {
+	if w == 1 {
+		return uint16(s.loadByte(seg, off))
+	}
+	return s.loadWord(seg, off)
+}
+
+func (s *Simulator) storeWidth(w int, seg, off uint16, v uint16) {
+	if w == 1 {
+		s.storeByte(seg, off, byte(v))
+	} else {
+		s.storeWord(seg, off, v)
+	}
+}
+
+// push decrements sp and stores v at ss:sp. Both wrap around within the
+// stack segment.
+func (s *Simulator) push(v uint16) {
+	ss, sp := s.Reg(decoder.SS), s.Reg(decoder.SP)-2
+	s.SetReg(decoder.SP, sp)
+	s.storeWord(ss, sp, v)
+	s.Stack = append(s.Stack, StackOp{seg: ss, off: sp, v: v})
+}
+
+// pop returns the word at ss:sp and increments sp.
+func (s *Simulator) pop() uint16 {
+	ss, sp := s.Reg(decoder.SS), s.Reg(decoder.SP)
+	v := s.loadWord(ss, sp)
+	s.SetReg(decoder.SP, sp+2)
+	s.Stack = append(s.Stack, StackOp{pop: true, seg: ss, off: sp, v: v})
+	return v
+}
+
+// StackOp is a push or pop of v at seg:off, kept so that the caller can print
+// them.
+type StackOp struct {
+	pop      bool
+	seg, off uint16
+	v        uint16
+}
+
+func (o StackOp) String() string {
+	name := "push"
+	if o.pop {
+		name = "pop"
+	}
+	return fmt.Sprintf("%s 0x%x at %04x:%04x", name, o.v, o.seg, o.off)
+}
+
+// Reg returns the value of r, which is a byte for al to bh.
+func (s *Simulator) Reg(r decoder.Register) uint16 {
+	v := s.Regs[r.Index()]
+	switch {
+	case r.Width() == 2:
+		return v
+	case r.High():
+		return v >> 8
+	}
+	return v & 0x00ff
+}
+
+// SetReg sets r to data, keeping the other half of the word for al to bh.
+func (s *Simulator) SetReg(r decoder.Register, data uint16) {
+	v := &s.Regs[r.Index()]
+	switch {
+	case r.Width() == 2:
+		*v = data
+	case r.High():
+		*v = (*v & 0x00ff) | (data << 8)
+	default:
+		*v = (*v & 0xff00) | (data & 0x00ff)
+	}
+}
+
+// Flags is the flags register, with each flag at its bit in FLAGS.
+type Flags uint16
+
+const (
+	flagCF Flags = 1 << 0
+	flagPF Flags = 1 << 2
+	flagAF Flags = 1 << 4
+	flagZF Flags = 1 << 6
+	flagSF Flags = 1 << 7
+	flagTF Flags = 1 << 8
+	flagIF Flags = 1 << 9
+	flagDF Flags = 1 << 10
+	flagOF Flags = 1 << 11
+)
+
+// setTo sets f if on is set, otherwise clears it.
+func (sf *Flags) setTo(f Flags, on bool) {
+	if on {
+		*sf |= f
+	} else {
+		*sf &^= f
+	}
+}
+
+func (sf Flags) isSet(flag Flags) bool {
+	return sf&flag == flag
+}
+
+// lowFlags are the flags in the low byte of FLAGS, which lahf and sahf move to
+// and from ah.
+const lowFlags = flagSF | flagZF | flagAF | flagPF | flagCF
+
+// unusedFlags are the bits of FLAGS that aren't flags but read as 1 on the
+// 8086: bit 1 and bits 12 to 15.
+const unusedFlags = 0xf002
+
+// allFlags are the flags there are, which popf sets from the stack.
+const allFlags = lowFlags | flagTF | flagIF | flagDF | flagOF
+
+// flagNames is the letter for each flag, in the order they're printed.
+var flagNames = []struct {
+	name string
+	flag Flags
+}{
+	{"C", flagCF},
+	{"P", flagPF},
+	{"A", flagAF},
+	{"Z", flagZF},
+	{"S", flagSF},
+	{"O", flagOF},
+	{"I", flagIF},
+	{"D", flagDF},
+	{"T", flagTF},
+}
+
+// String returns the letters of the flags that are set, e.g. "PZ".
+func (sf Flags) String() string {
+	var sb strings.Builder
+	for _, f := range flagNames {
+		if sf.isSet(f.flag) {
+			sb.WriteString(f.name)
+		}
+	}
+	return sb.String()
+}
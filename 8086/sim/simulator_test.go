@@ -0,0 +1,144 @@
+package sim
+
+import (
+	"testing"
+
+	"8086/decoder"
+)
+
+func TestSimulatorAddress(t *testing.T) {
+	s := New()
+	s.SetReg(decoder.BX, 0x1000)
+	s.SetReg(decoder.BP, 0x2000)
+	s.SetReg(decoder.SI, 0xf000)
+	s.SetReg(decoder.DS, 0x0100)
+	s.SetReg(decoder.SS, 0x0200)
+	s.SetReg(decoder.ES, 0xffff)
+
+	tests := []struct {
+		ea   decoder.EffectiveAddress
+		want int
+	}{
+		{decoder.EffectiveAddress{Displacement: 16}, 0x01010},
+		{decoder.EffectiveAddress{Base: decoder.BX, Displacement: -1}, 0x01fff},
+		{decoder.EffectiveAddress{Base: decoder.BP, Displacement: 4}, 0x04004},
+		{decoder.EffectiveAddress{Base: decoder.BP, Segment: decoder.DS}, 0x03000},
+		// The offset wraps around at 64 KiB
+		{decoder.EffectiveAddress{Base: decoder.BX, Index: decoder.SI}, 0x01000},
+		// The physical address wraps around at 1 MiB
+		{decoder.EffectiveAddress{Displacement: 0x20, Segment: decoder.ES}, 0x00010},
+	}
+	for _, tt := range tests {
+		if got := s.physical(s.address(tt.ea)); got != tt.want {
+			t.Errorf("[%v] with segment %v = %#05x, want %#05x", tt.ea, tt.ea.DefaultSegment(), got, tt.want)
+		}
+	}
+}
+
+func TestSimulatorLoadStore(t *testing.T) {
+	s := New()
+	s.storeWord(0x0100, 0x0010, 0x1234)
+	if got := s.mem[0x1010:0x1012]; got[0] != 0x34 || got[1] != 0x12 {
+		t.Errorf("stored word as % x, want 34 12", got)
+	}
+	if got := s.loadWord(0x0101, 0x0000); got != 0x1234 {
+		t.Errorf("loaded %#04x through another segment, want 0x1234", got)
+	}
+	if got := s.loadByte(0x0100, 0x0011); got != 0x12 {
+		t.Errorf("loaded byte %#02x, want 0x12", got)
+	}
+
+	// The high byte of a word at offset 0xffff is at offset 0
+	s.storeWord(0x0200, 0xffff, 0x5678)
+	if s.mem[0x2000+0xffff] != 0x78 || s.mem[0x2000] != 0x56 {
+		t.Errorf("word at 0xffff stored as %#02x, %#02x, want 0x78, 0x56", s.mem[0x2000+0xffff], s.mem[0x2000])
+	}
+	if got := s.loadWord(0x0200, 0xffff); got != 0x5678 {
+		t.Errorf("loaded word at 0xffff as %#04x, want 0x5678", got)
+	}
+}
+
+func TestSimulatorStack(t *testing.T) {
+	s := New()
+	s.SetReg(decoder.SS, 0x0100)
+	s.push(0x1234)
+	if sp := s.Reg(decoder.SP); sp != 0xfffe {
+		t.Errorf("sp after a push from 0 = %#04x, want 0xfffe", sp)
+	}
+	if got := s.mem[0x1000+0xfffe]; got != 0x34 {
+		t.Errorf("pushed low byte %#02x, want 0x34", got)
+	}
+
+	// A word pushed at an odd sp of 1 straddles the end of the segment
+	s.SetReg(decoder.SP, 1)
+	s.push(0x5678)
+	if s.mem[0x1000+0xffff] != 0x78 || s.mem[0x1000] != 0x56 {
+		t.Errorf("word pushed at 0xffff stored as %#02x, %#02x, want 0x78, 0x56", s.mem[0x1000+0xffff], s.mem[0x1000])
+	}
+	if got := s.pop(); got != 0x5678 {
+		t.Errorf("popped %#04x, want 0x5678", got)
+	}
+	if sp := s.Reg(decoder.SP); sp != 1 {
+		t.Errorf("sp after the pop = %#04x, want 1", sp)
+	}
+	if len(s.Stack) != 3 || !s.Stack[2].pop || s.Stack[2].off != 0xffff {
+		t.Errorf("recorded stack operations %v, want the pop at 0xffff last", s.Stack)
+	}
+}
+
+// step runs the instruction at cs:ip.
+func step(t *testing.T, s *Simulator) {
+	t.Helper()
+	in, _, err := decoder.Decode(s.mem[s.physical(s.Reg(decoder.CS), s.IP):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Exec(in); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimulatorRaiseInterrupt(t *testing.T) {
+	s := New()
+	s.SetReg(decoder.CS, 0x1000)
+	s.SetReg(decoder.SP, 0x100)
+	// cli; inc ax; sti; inc ax; hlt, and a timer handler of inc bx; iret
+	s.Load([]byte{0xfa, 0x40, 0xfb, 0x40, 0xf4})
+	copy(s.mem[0x10010:], []byte{0x43, 0xcf})
+	s.storeWord(0, 8*4, 0x0010)
+	s.storeWord(0, 8*4+2, 0x1000)
+
+	step(t, s) // cli
+	if s.RaiseInterrupt(8) {
+		t.Fatal("interrupt taken with IF clear")
+	}
+	step(t, s) // inc ax
+	step(t, s) // sti
+	if s.IP != 3 {
+		t.Fatalf("pending interrupt taken at ip %#x, before the instruction after sti", s.IP)
+	}
+	step(t, s) // inc ax
+	if s.IP != 0x10 || s.Flags.isSet(flagIF) {
+		t.Fatalf("after the pending interrupt ip = %#x, flags = %v, want 0x10 with IF clear", s.IP, s.Flags)
+	}
+	if got := s.loadWord(0, 0xfa); got != 4 {
+		t.Errorf("pushed ip %#x, want 4", got)
+	}
+	step(t, s) // inc bx
+	step(t, s) // iret
+	if s.IP != 4 || s.Reg(decoder.CS) != 0x1000 || !s.Flags.isSet(flagIF) || s.Reg(decoder.SP) != 0x100 {
+		t.Fatalf("after iret cs:ip = %#x:%#x, flags = %v, sp = %#x, want 0x1000:0x4 with IF set and sp 0x100",
+			s.Reg(decoder.CS), s.IP, s.Flags, s.Reg(decoder.SP))
+	}
+
+	// An interrupt wakes the simulator from hlt, and returns after it
+	step(t, s) // hlt
+	if !s.Halted || !s.RaiseInterrupt(8) || s.Halted {
+		t.Fatal("interrupt didn't wake the simulator from hlt")
+	}
+	step(t, s) // inc bx
+	step(t, s) // iret
+	if s.IP != 5 || s.Reg(decoder.AX) != 2 || s.Reg(decoder.BX) != 2 {
+		t.Errorf("finished at ip %#x with ax = %d, bx = %d, want 5, 2 and 2", s.IP, s.Reg(decoder.AX), s.Reg(decoder.BX))
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"8086/decoder"
+)
+
+// parseEntries parses the comma separated offsets of -entry, and returns them
+// after offset 0, which is always an entry point.
+func parseEntries(s string, size int) ([]int, error) {
+	entries := []int{0}
+	if s == "" {
+		return entries, nil
+	}
+	for _, e := range strings.Split(s, ",") {
+		entry, err := strconv.ParseUint(strings.TrimSpace(e), 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		if int(entry) >= size {
+			return nil, fmt.Errorf("%#x is past the end of the input", entry)
+		}
+		entries = append(entries, int(entry))
+	}
+	return entries, nil
+}
+
+// flow describes where execution can go after an instruction.
+type flow struct {
+	// next is set if execution can continue with the following instruction
+	next bool
+	// target is the offset of a relative jump or call, or -1
+	target int
+	// call is set if target is a call, which returns to the next instruction
+	call bool
+}
+
+// flowOf returns where execution can go after in. Far and indirect jumps and
+// calls have no target as it can't be known from the instruction alone.
+func flowOf(in decoder.Instruction) flow {
+	f := flow{next: true, target: -1}
+	switch in.Name {
+	case "jmp", "ret", "retf", "iret", "hlt":
+		f.next = false
+	case "call":
+		f.call = true
+	}
+	for _, o := range in.Operands() {
+		if o.Kind == decoder.OperandRelative {
+			f.target = in.Target(o)
+		}
+	}
+	return f
+}
+
+// traceCode decodes the code reachable from the entry points by following
+// fallthrough, conditional jumps, loop, jcxz and direct jumps and calls. It
+// returns the instructions found keyed by offset. A path stops at bytes that
+// don't decode, or that would overlap an instruction already found.
+func traceCode(data []byte, entries []int) map[int]decoder.Instruction {
+	code := map[int]decoder.Instruction{}
+	// owner is the offset of the instruction covering each byte, or -1
+	owner := make([]int, len(data))
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	it := decoder.NewIterator(data)
+	queue := append([]int(nil), entries...)
+	for len(queue) > 0 {
+		off := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		for off >= 0 && off < len(data) && owner[off] == -1 {
+			it.Seek(off)
+			in, err := it.Next()
+			if err != nil || overlaps(owner, off, in.Length) {
+				break
+			}
+			code[off] = in
+			for i := off; i < off+in.Length; i++ {
+				owner[i] = off
+			}
+
+			f := flowOf(in)
+			if f.target >= 0 {
+				queue = append(queue, f.target)
+			}
+			if !f.next {
+				break
+			}
+			off += in.Length
+		}
+	}
+	return code
+}
+
+func overlaps(owner []int, off, length int) bool {
+	for i := off; i < off+length; i++ {
+		if owner[i] != -1 {
+			return true
+		}
+	}
+	return false
+}
+
+// dataEnd returns the end of the bytes from start that aren't code, which is
+// the start of the next instruction or the end of the input.
+func dataEnd(code map[int]decoder.Instruction, start, end int) int {
+	for i := start; i < end; i++ {
+		if _, ok := code[i]; ok {
+			return i
+		}
+	}
+	return end
+}